@@ -0,0 +1,78 @@
+package sqlitemeta
+
+import (
+	"fmt"
+	"strings"
+)
+
+// An Affinity is the type affinity of a column, i.e. the
+// preferred storage class for values stored in the column.
+//
+// See https://sqlite.org/datatype3.html#type_affinity for
+// details.
+type Affinity uint
+
+const (
+	// AffinityBlob columns store values as they are provided,
+	// without conversion.
+	AffinityBlob Affinity = iota
+
+	// AffinityText columns store numeric values as text.
+	AffinityText
+
+	// AffinityNumeric columns store text values as integers
+	// or reals where a lossless conversion is possible.
+	AffinityNumeric
+
+	// AffinityInteger columns behave like AffinityNumeric
+	// columns, except that they store whole-number reals as
+	// integers.
+	AffinityInteger
+
+	// AffinityReal columns behave like AffinityNumeric columns,
+	// except that they store integers as reals.
+	AffinityReal
+)
+
+// String returns the name of an Affinity, e.g. "INTEGER".
+func (a Affinity) String() string {
+	switch a {
+	case AffinityBlob:
+		return "BLOB"
+	case AffinityText:
+		return "TEXT"
+	case AffinityNumeric:
+		return "NUMERIC"
+	case AffinityInteger:
+		return "INTEGER"
+	case AffinityReal:
+		return "REAL"
+	default:
+		return fmt.Sprintf("Affinity(%d)", uint(a))
+	}
+}
+
+// TypeAffinity returns the type affinity that SQLite assigns
+// to a column with the given declared type.
+func TypeAffinity(declType string) Affinity {
+
+	t := strings.ToUpper(declType)
+
+	switch {
+	case strings.Contains(t, "INT"):
+		return AffinityInteger
+	case strings.Contains(t, "CHAR"), strings.Contains(t, "CLOB"), strings.Contains(t, "TEXT"):
+		return AffinityText
+	case strings.Contains(t, "BLOB"), strings.TrimSpace(t) == "":
+		return AffinityBlob
+	case strings.Contains(t, "REAL"), strings.Contains(t, "FLOA"), strings.Contains(t, "DOUB"):
+		return AffinityReal
+	default:
+		return AffinityNumeric
+	}
+}
+
+// Affinity returns the type affinity of a Column.
+func (c Column) Affinity() Affinity {
+	return TypeAffinity(c.Type)
+}
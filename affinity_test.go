@@ -0,0 +1,74 @@
+package sqlitemeta_test
+
+import (
+	"testing"
+
+	meta "github.com/deepilla/sqlitemeta"
+)
+
+func TestTypeAffinity(t *testing.T) {
+
+	data := []struct {
+		Types    []string
+		Affinity meta.Affinity
+	}{
+		{
+			Types: []string{
+				"INT",
+				"integer",
+				"TINYINT",
+				"BIGINT",
+				"UNSIGNED BIG INT",
+				"INT2",
+				"POINT", // Contains "INT"
+			},
+			Affinity: meta.AffinityInteger,
+		},
+		{
+			Types: []string{
+				"CHARACTER(20)",
+				"varchar(255)",
+				"NATIVE CHARACTER(70)",
+				"NVARCHAR(100)",
+				"TEXT",
+				"CLOB",
+			},
+			Affinity: meta.AffinityText,
+		},
+		{
+			Types: []string{
+				"",
+				"BLOB",
+			},
+			Affinity: meta.AffinityBlob,
+		},
+		{
+			Types: []string{
+				"REAL",
+				"DOUBLE",
+				"DOUBLE PRECISION",
+				"float",
+			},
+			Affinity: meta.AffinityReal,
+		},
+		{
+			Types: []string{
+				"NUMERIC",
+				"DECIMAL(10,5)",
+				"BOOLEAN",
+				"DATE",
+				"DATETIME",
+				"STRING", // Not TEXT!
+			},
+			Affinity: meta.AffinityNumeric,
+		},
+	}
+
+	for _, test := range data {
+		for _, typ := range test.Types {
+			if got := meta.TypeAffinity(typ); got != test.Affinity {
+				t.Errorf("TypeAffinity(%q): Expected %v, got %v", typ, test.Affinity, got)
+			}
+		}
+	}
+}
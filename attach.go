@@ -0,0 +1,83 @@
+package sqlitemeta
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// Attach attaches the database file at the given path to a
+// database connection under the given name, and returns the
+// corresponding Schema. Unlike DB, Attach verifies that the
+// database is available before returning.
+//
+// Attached databases belong to a single connection, which is why
+// Attach takes a *sql.Conn rather than a *sql.DB: attaching via a
+// connection pool would make the database visible to whichever
+// connection happened to run the ATTACH statement. Pass the same
+// connection to the returned Schema's methods, e.g.
+//
+//	conn, err := db.Conn(ctx)
+//	...
+//	aux, err := sqlitemeta.Attach(ctx, conn, "aux.db", "aux")
+//	...
+//	tables, err := aux.TableNames(conn)
+//
+// The path may be any filename accepted by SQLite's ATTACH
+// statement, including ":memory:" and URI filenames.
+func Attach(ctx context.Context, conn *sql.Conn, path, name string) (*Schema, error) {
+
+	_, err := conn.ExecContext(ctx, "ATTACH DATABASE ? AS ?", path, name)
+	if err != nil {
+		return nil, fmt.Errorf("could not attach database %s: %s", name, err)
+	}
+
+	s := DB(name)
+
+	ok, err := s.exists(connQuerier{ctx, conn})
+	if err != nil {
+		return nil, fmt.Errorf("could not attach database %s: %s", name, err)
+	}
+	if !ok {
+		return nil, fmt.Errorf("could not attach database %s: database not found after ATTACH", name)
+	}
+
+	return s, nil
+}
+
+// Detach detaches the named database from a database connection.
+// It returns an error if no such database is attached to the
+// connection.
+func Detach(ctx context.Context, conn *sql.Conn, name string) error {
+
+	_, err := conn.ExecContext(ctx, "DETACH DATABASE ?", name)
+	if err != nil {
+		return fmt.Errorf("could not detach database %s: %s", name, err)
+	}
+
+	ok, err := DB(name).exists(connQuerier{ctx, conn})
+	if err != nil {
+		return fmt.Errorf("could not detach database %s: %s", name, err)
+	}
+	if ok {
+		return fmt.Errorf("could not detach database %s: database still attached after DETACH", name)
+	}
+
+	return nil
+}
+
+// Exists reports whether a database with this Schema's name is
+// attached to the given database connection. The temp database
+// always exists.
+//
+// To check a database attached with Attach, pass the connection
+// that it was attached to.
+func (s *Schema) Exists(db Querier) (bool, error) {
+
+	ok, err := s.exists(db)
+	if err != nil {
+		return false, fmt.Errorf("could not check database %s: %s", s.name, err)
+	}
+
+	return ok, nil
+}
@@ -0,0 +1,247 @@
+package sqlitemeta_test
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+
+	meta "github.com/deepilla/sqlitemeta"
+)
+
+func TestAttach(t *testing.T) {
+	testWithDB(t, testAttach)
+}
+
+func testAttach(t *testing.T, db *sql.DB) {
+
+	ctx := context.Background()
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatalf("Could not get connection: %s", err)
+	}
+	defer conn.Close()
+
+	schema, err := meta.Attach(ctx, conn, ":memory:", "aux 1")
+	if err != nil {
+		t.Fatalf("Attach returned error %s", err)
+	}
+
+	if schema.Name() != "aux 1" {
+		t.Errorf("Expected Schema aux 1, got %s", schema.Name())
+	}
+
+	_, err = meta.Attach(ctx, conn, ":memory:", "aux 1")
+	if err == nil || !strings.HasPrefix(err.Error(), "could not attach database aux 1: ") {
+		t.Errorf("Expected an error attaching aux 1 twice, got %v", err)
+	}
+
+	if _, err := conn.ExecContext(ctx, `CREATE TABLE "aux 1".test (a, b)`); err != nil {
+		t.Fatalf("Could not create table: %s", err)
+	}
+
+	// Later calls on the same connection see the attached
+	// database, even while other connections are in use.
+	if err := db.Ping(); err != nil {
+		t.Fatalf("Ping returned error %s", err)
+	}
+
+	names, err := schema.TableNames(conn)
+	if err != nil {
+		t.Fatalf("TableNames returned error %s", err)
+	}
+	if exp := []string{"test"}; !equalStringSlices(exp, names) {
+		t.Errorf("Expected tables %v, got %v", exp, names)
+	}
+
+	columns, err := meta.Columns(conn, "test")
+	if err != nil {
+		t.Fatalf("Columns returned error %s", err)
+	}
+	if len(columns) != 2 {
+		t.Errorf("Expected 2 columns, got %d", len(columns))
+	}
+
+	for _, c := range []struct {
+		Schema *meta.Schema
+		Exp    bool
+	}{
+		{meta.Main, true},
+		{meta.Temp, true},
+		{schema, true},
+		{meta.DB("AUX 1"), true},
+		{meta.DB("xxxxx"), false},
+	} {
+		ok, err := c.Schema.Exists(conn)
+		if err != nil {
+			t.Fatalf("Exists(%s) returned error %s", c.Schema.Name(), err)
+		}
+		if ok != c.Exp {
+			t.Errorf("Expected Exists(%s) to return %t, got %t", c.Schema.Name(), c.Exp, ok)
+		}
+	}
+
+	if err := meta.Detach(ctx, conn, "aux 1"); err != nil {
+		t.Fatalf("Detach returned error %s", err)
+	}
+
+	err = meta.Detach(ctx, conn, "aux 1")
+	if exp := "could not detach database aux 1: no such database: aux 1"; err == nil || err.Error() != exp {
+		t.Errorf("Expected error %q, got %v", exp, err)
+	}
+
+	ok, err := schema.Exists(conn)
+	if err != nil {
+		t.Fatalf("Exists returned error %s", err)
+	}
+	if ok {
+		t.Errorf("Expected aux 1 not to exist after Detach")
+	}
+}
+
+func TestAttachedSchemaTools(t *testing.T) {
+	testWithDB(t, testAttachedSchemaTools)
+}
+
+func testAttachedSchemaTools(t *testing.T, db *sql.DB) {
+
+	ctx := context.Background()
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatalf("Could not get connection: %s", err)
+	}
+	defer conn.Close()
+
+	aux, err := meta.Attach(ctx, conn, ":memory:", "aux")
+	if err != nil {
+		t.Fatalf("Attach returned error %s", err)
+	}
+	defer meta.Detach(ctx, conn, "aux")
+
+	for _, q := range []string{
+		"CREATE TABLE aux.users (id INTEGER PRIMARY KEY, name TEXT)",
+		"CREATE TABLE aux.posts (id INTEGER PRIMARY KEY, user_id INTEGER REFERENCES users(id), title TEXT)",
+	} {
+		if _, err := conn.ExecContext(ctx, q); err != nil {
+			t.Fatalf("Could not run %q: %s", q, err)
+		}
+	}
+
+	// Every function that takes a Querier should see the
+	// attached database when given the connection.
+	docs, err := meta.SchemaDocs(conn)
+	if err != nil {
+		t.Fatalf("SchemaDocs returned error %s", err)
+	}
+
+	found := false
+	for _, d := range docs {
+		found = found || d.Name == "aux" && len(d.Tables) == 2
+	}
+	if !found {
+		t.Errorf("Expected docs for the 2 tables in aux, got %v", docs)
+	}
+
+	opts := &meta.DiagramOptions{Schema: aux}
+
+	var buf bytes.Buffer
+	if err := meta.WriteDOT(&buf, conn, opts); err != nil {
+		t.Fatalf("WriteDOT returned error %s", err)
+	}
+	if !strings.Contains(buf.String(), "posts") {
+		t.Errorf("Expected DOT output to contain posts, got\n%s", buf.String())
+	}
+
+	buf.Reset()
+	if err := meta.WriteMermaid(&buf, conn, opts); err != nil {
+		t.Fatalf("WriteMermaid returned error %s", err)
+	}
+	if !strings.Contains(buf.String(), "posts") {
+		t.Errorf("Expected Mermaid output to contain posts, got\n%s", buf.String())
+	}
+
+	buf.Reset()
+	if err := meta.GenerateStructs(&buf, conn, &meta.StructOptions{Package: "models", Schema: aux}); err != nil {
+		t.Fatalf("GenerateStructs returned error %s", err)
+	}
+	if !strings.Contains(buf.String(), "UserID") {
+		t.Errorf("Expected generated structs to contain UserID, got\n%s", buf.String())
+	}
+
+	findings, err := meta.Lint(conn, &meta.LintOptions{Schema: aux})
+	if err != nil {
+		t.Fatalf("Lint returned error %s", err)
+	}
+
+	var fix string
+	for _, f := range findings {
+		if f.Rule == meta.RuleUnindexedForeignKey && f.Schema == "aux" {
+			fix = f.Fix
+		}
+	}
+
+	// The suggested index should be created in aux.
+	if exp := `CREATE INDEX "aux"."idx_posts_user_id" ON "posts" ("user_id");`; fix != exp {
+		t.Errorf("Expected fix %q for an unindexed foreign key in aux, got %v", exp, findings)
+	}
+
+	names, err := meta.NewCache(conn).Schema(aux).TableNames()
+	if err != nil {
+		t.Fatalf("Cache TableNames returned error %s", err)
+	}
+	if exp := []string{"posts", "users"}; !equalStringSlices(exp, names) {
+		t.Errorf("Expected cached tables %v, got %v", exp, names)
+	}
+
+	for _, q := range []string{
+		"SELECT * FROM aux.posts WHERE user_id = 1",
+		"SELECT * FROM aux.posts AS p WHERE p.user_id = 1",
+	} {
+		plan, err := meta.QueryPlan(conn, q)
+		if err != nil {
+			t.Fatalf("QueryPlan returned error %s", err)
+		}
+		if scans := plan.FullScans(); len(scans) != 1 || scans[0].Schema != "aux" || scans[0].Table != "posts" {
+			t.Errorf("%s: Expected a full scan of aux.posts, got %v", q, scans)
+		}
+	}
+
+	if _, err := conn.ExecContext(ctx, fix); err != nil {
+		t.Fatalf("Could not create index: %s", err)
+	}
+
+	plan, err := meta.QueryPlan(conn, "SELECT * FROM aux.posts WHERE user_id = 1")
+	if err != nil {
+		t.Fatalf("QueryPlan returned error %s", err)
+	}
+	if names := plan.IndexNames(); len(names) != 1 || names[0] != "idx_posts_user_id" {
+		t.Errorf("Expected plan to use idx_posts_user_id, got %v", names)
+	}
+	plan.Walk(func(n *meta.PlanNode) {
+		if n.IndexName != "" && n.Index == nil {
+			t.Errorf("Expected index %s to be linked to its metadata", n.IndexName)
+		}
+	})
+
+	// RecommendIndexes only copies the main database, but it
+	// should accept a connection.
+	for _, q := range []string{
+		"DROP TABLE IF EXISTS attach_orders",
+		"CREATE TABLE attach_orders (id INTEGER PRIMARY KEY, status TEXT)",
+	} {
+		if _, err := conn.ExecContext(ctx, q); err != nil {
+			t.Fatalf("Could not run %q: %s", q, err)
+		}
+	}
+
+	recs, err := meta.RecommendIndexes(conn, []meta.Query{{SQL: "SELECT * FROM attach_orders WHERE status = 'new'"}})
+	if err != nil {
+		t.Fatalf("RecommendIndexes returned error %s", err)
+	}
+	if len(recs) != 1 || recs[0].Table != "attach_orders" {
+		t.Errorf("Expected an index on attach_orders, got %v", recs)
+	}
+}
@@ -0,0 +1,93 @@
+package sqlitemeta_test
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+
+	meta "github.com/deepilla/sqlitemeta"
+)
+
+// benchDB returns a database with a table that has 50 columns
+// and 10 indexes of 5 columns each.
+func benchDB(b *testing.B) (*sql.DB, func()) {
+
+	db, close, err := memoryDB()
+	if err != nil {
+		b.Fatalf("Could not open db: %s", err)
+	}
+
+	// Use a single connection so that every iteration queries
+	// the same in-memory database.
+	db.SetMaxOpenConns(1)
+
+	cols := ""
+	for i := 0; i < 50; i++ {
+		if i > 0 {
+			cols += ", "
+		}
+		cols += fmt.Sprintf("c%d INTEGER NOT NULL DEFAULT %d", i, i)
+	}
+
+	sqls := []string{
+		"CREATE TABLE bench (" + cols + ")",
+	}
+
+	for i := 0; i < 10; i++ {
+		sqls = append(sqls, fmt.Sprintf("CREATE INDEX bench_%d ON bench (c%d, c%d, c%d, c%d, c%d)", i, i, i+10, i+20, i+30, i+40))
+	}
+
+	for _, q := range sqls {
+		if _, err := db.Exec(q); err != nil {
+			close()
+			b.Fatalf("db.Exec %q returned error %s", q, err)
+		}
+	}
+
+	return db, close
+}
+
+func BenchmarkColumns(b *testing.B) {
+
+	db, close := benchDB(b)
+	defer close()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := meta.Columns(db, "bench"); err != nil {
+			b.Fatalf("Columns returned error %s", err)
+		}
+	}
+}
+
+func BenchmarkIndexes(b *testing.B) {
+
+	db, close := benchDB(b)
+	defer close()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := meta.Indexes(db, "bench"); err != nil {
+			b.Fatalf("Indexes returned error %s", err)
+		}
+	}
+}
+
+func BenchmarkIndexColumns(b *testing.B) {
+
+	db, close := benchDB(b)
+	defer close()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := meta.IndexColumnsAux(db, "bench_0"); err != nil {
+			b.Fatalf("IndexColumnsAux returned error %s", err)
+		}
+	}
+}
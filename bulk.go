@@ -0,0 +1,288 @@
+package sqlitemeta
+
+import (
+	"fmt"
+	"strings"
+)
+
+// The functions in this file fetch metadata for every table in a
+// database with a single query, rather than one query per table.
+// Each returns a map keyed by table name (as it appears in
+// sqlite_master). Virtual tables are included but views are not.
+//
+// Virtual tables are left out of the bulk queries, which would
+// otherwise fail if any virtual table's module is not available.
+// They have no indexes or foreign keys, so only AllColumns has
+// to query them, one table at a time.
+
+// AllColumns returns column information for every table in the
+// main database. Use the Schema.AllColumns method to query other
+// databases.
+func AllColumns(db Querier) (map[string][]Column, error) {
+	return Main.AllColumns(db)
+}
+
+// AllColumns returns column information for every table in this
+// Schema, keyed by table name. The results are the same as
+// calling Columns for each table, but much faster for databases
+// with many tables. Virtual tables whose module is not available
+// are left out.
+func (s *Schema) AllColumns(db Querier) (map[string][]Column, error) {
+
+	if s.name == "" {
+		return Main.AllColumns(db)
+	}
+
+	master, err := s.masterTable(db)
+	if err != nil {
+		return nil, fmt.Errorf("could not get columns: %s", err)
+	}
+
+	q :=
+		`SELECT
+			m.name,
+			p.cid,
+			p.name,
+			p.type,
+			p."notnull",
+			p.dflt_value,
+			p.pk
+		FROM
+			` + master + ` m
+		INNER JOIN
+			pragma_table_info(m.name, ?) p
+		WHERE
+			m.type = 'table' AND
+			m.sql NOT LIKE 'CREATE VIRTUAL TABLE %'
+		ORDER BY
+			m.name, p.cid`
+
+	var table string
+	var c Column
+	columns := map[string][]Column{}
+
+	err = s.queryPragma(db, func() error {
+		return scanRows(db, q, []interface{}{s.name}, func() {
+			columns[table] = append(columns[table], c)
+		}, append([]interface{}{&table}, c.dest()...)...)
+	}, func(string) error {
+		return s.eachTable(db, func(tableName string) error {
+			cols, err := s.Columns(db, tableName)
+			columns[tableName] = cols
+			return err
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not get columns: %s", err)
+	}
+
+	names, err := s.virtualTableNames(db)
+	if err != nil {
+		return nil, fmt.Errorf("could not get columns: %s", err)
+	}
+
+	for _, name := range names {
+		cols, err := s.Columns(db, name)
+		if err != nil {
+			if strings.Contains(err.Error(), "no such module: ") {
+				continue
+			}
+			return nil, fmt.Errorf("could not get columns: %s", err)
+		}
+		columns[name] = cols
+	}
+
+	return columns, nil
+}
+
+// AllIndexes returns index information for every table in the
+// main database. Use the Schema.AllIndexes method to query other
+// databases.
+func AllIndexes(db Querier) (map[string][]Index, error) {
+	return Main.AllIndexes(db)
+}
+
+// AllIndexes returns index information for every table in this
+// Schema, keyed by table name. Tables without indexes are not
+// included. The results are the same as calling Indexes for each
+// table, but much faster for databases with many tables.
+func (s *Schema) AllIndexes(db Querier) (map[string][]Index, error) {
+
+	if s.name == "" {
+		return Main.AllIndexes(db)
+	}
+
+	master, err := s.masterTable(db)
+	if err != nil {
+		return nil, fmt.Errorf("could not get indexes: %s", err)
+	}
+
+	q :=
+		`SELECT
+			m.name,
+			t1.name,
+			t1.origin,
+			t1."unique",
+			t1.partial,
+			t2.name
+		FROM
+			` + master + ` m
+		INNER JOIN
+			pragma_index_list(m.name, ?) t1
+		INNER JOIN
+			pragma_index_info(t1.name, ?) t2
+		WHERE
+			m.type = 'table' AND
+			m.sql NOT LIKE 'CREATE VIRTUAL TABLE %'
+		ORDER BY
+			m.name, t1.seq, t2.seqno`
+
+	var table string
+	var r indexRow
+	var tables []string
+	byTable := map[string][]indexRow{}
+	indexes := map[string][]Index{}
+
+	err = s.queryPragma(db, func() error {
+		return scanRows(db, q, []interface{}{s.name, s.name}, func() {
+			if _, ok := byTable[table]; !ok {
+				tables = append(tables, table)
+			}
+			byTable[table] = append(byTable[table], r)
+		}, append([]interface{}{&table}, r.dest()...)...)
+	}, func(string) error {
+		return s.eachTable(db, func(tableName string) error {
+			idxs, err := s.Indexes(db, tableName)
+			if len(idxs) > 0 {
+				indexes[tableName] = idxs
+			}
+			return err
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not get indexes: %s", err)
+	}
+
+	for _, table := range tables {
+		indexes[table] = indexesFromRows(byTable[table])
+	}
+
+	return indexes, nil
+}
+
+// AllForeignKeys returns foreign key information for every table
+// in the main database. Use the Schema.AllForeignKeys method to
+// query other databases.
+func AllForeignKeys(db Querier) (map[string][]ForeignKey, error) {
+	return Main.AllForeignKeys(db)
+}
+
+// AllForeignKeys returns foreign key information for every table
+// in this Schema, keyed by table name. Tables without foreign
+// keys are not included. The results are the same as calling
+// ForeignKeys for each table, but much faster for databases with
+// many tables.
+func (s *Schema) AllForeignKeys(db Querier) (map[string][]ForeignKey, error) {
+
+	if s.name == "" {
+		return Main.AllForeignKeys(db)
+	}
+
+	master, err := s.masterTable(db)
+	if err != nil {
+		return nil, fmt.Errorf("could not get foreign keys: %s", err)
+	}
+
+	q :=
+		`SELECT
+			m.name,
+			p.id,
+			p."table",
+			p."from",
+			p."to",
+			p.on_update,
+			p.on_delete
+		FROM
+			` + master + ` m
+		INNER JOIN
+			pragma_foreign_key_list(m.name, ?) p
+		WHERE
+			m.type = 'table' AND
+			m.sql NOT LIKE 'CREATE VIRTUAL TABLE %'
+		ORDER BY
+			m.name, p.id, p.seq`
+
+	var table string
+	var r foreignKeyRow
+	var tables []string
+	byTable := map[string][]foreignKeyRow{}
+	foreignKeys := map[string][]ForeignKey{}
+
+	err = s.queryPragma(db, func() error {
+		return scanRows(db, q, []interface{}{s.name}, func() {
+			if _, ok := byTable[table]; !ok {
+				tables = append(tables, table)
+			}
+			byTable[table] = append(byTable[table], r)
+		}, append([]interface{}{&table}, r.dest()...)...)
+	}, func(string) error {
+		return s.eachTable(db, func(tableName string) error {
+			fks, err := s.ForeignKeys(db, tableName)
+			if len(fks) > 0 {
+				foreignKeys[tableName] = fks
+			}
+			return err
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not get foreign keys: %s", err)
+	}
+
+	for _, table := range tables {
+		foreignKeys[table] = foreignKeysFromRows(byTable[table])
+	}
+
+	return foreignKeys, nil
+}
+
+// eachTable calls fn for each table in this Schema, except for
+// virtual tables. It is used when pragma functions aren't
+// available.
+func (s *Schema) eachTable(db Querier, fn func(tableName string) error) error {
+
+	objects, err := s.masterObjects(db)
+	if err != nil {
+		return err
+	}
+
+	for _, obj := range objects {
+		if obj.Type != "table" || obj.SQL.Valid && isVirtualTableSQL(obj.SQL.String) {
+			continue
+		}
+		if err := fn(obj.Name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// virtualTableNames returns the names of the virtual tables in
+// this Schema.
+func (s *Schema) virtualTableNames(db Querier) ([]string, error) {
+
+	objects, err := s.masterObjects(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+
+	for _, obj := range objects {
+		if obj.Type == "table" && obj.SQL.Valid && isVirtualTableSQL(obj.SQL.String) {
+			names = append(names, obj.Name)
+		}
+	}
+
+	return names, nil
+}
@@ -0,0 +1,243 @@
+package sqlitemeta_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"testing"
+
+	meta "github.com/deepilla/sqlitemeta"
+)
+
+func TestBulk(t *testing.T) {
+	testWithDB(t, testBulk)
+}
+
+func TestBulkLegacy(t *testing.T) {
+	defer meta.SetLegacyPragmas(true)()
+	testWithDB(t, testBulk)
+}
+
+func testBulk(t *testing.T, db *sql.DB) {
+
+	exec(t, db, []string{
+		"DROP TABLE IF EXISTS comments",
+		"DROP TABLE IF EXISTS posts",
+		"DROP TABLE IF EXISTS users",
+		"DROP VIEW IF EXISTS post_titles",
+		"CREATE TABLE users (id INTEGER PRIMARY KEY, email TEXT NOT NULL UNIQUE, name TEXT DEFAULT 'anon')",
+		"CREATE TABLE posts (id INTEGER PRIMARY KEY, user_id INTEGER REFERENCES users (id) ON DELETE CASCADE, title TEXT, UNIQUE (user_id, title))",
+		"CREATE INDEX posts_title ON posts (title DESC, lower(title)) WHERE title IS NOT NULL",
+		"CREATE TABLE comments (post_id, user_id, body, FOREIGN KEY (post_id, user_id) REFERENCES posts (id, user_id) ON UPDATE SET NULL, FOREIGN KEY (user_id) REFERENCES users)",
+		"CREATE VIEW post_titles AS SELECT title FROM posts",
+		"ATTACH DATABASE ':memory:' AS aux",
+		"CREATE TABLE aux.tags (id INTEGER PRIMARY KEY, tag TEXT UNIQUE)",
+		"CREATE VIRTUAL TABLE aux.notes USING fts4(title, body)",
+	})
+
+	for _, s := range []*meta.Schema{meta.Main, meta.DB("aux")} {
+
+		tables, err := s.TableNames(db)
+		if err != nil {
+			t.Fatalf("TableNames returned error %s", err)
+		}
+
+		columns, err := s.AllColumns(db)
+		if err != nil {
+			t.Fatalf("%s: AllColumns returned error %s", s.Name(), err)
+		}
+
+		indexes, err := s.AllIndexes(db)
+		if err != nil {
+			t.Fatalf("%s: AllIndexes returned error %s", s.Name(), err)
+		}
+
+		foreignKeys, err := s.AllForeignKeys(db)
+		if err != nil {
+			t.Fatalf("%s: AllForeignKeys returned error %s", s.Name(), err)
+		}
+
+		if len(columns) != len(tables) {
+			t.Errorf("%s: Expected columns for %d tables, got %d", s.Name(), len(tables), len(columns))
+		}
+
+		for _, table := range tables {
+
+			cols, err := s.Columns(db, table)
+			if err != nil {
+				t.Fatalf("Columns returned error %s", err)
+			}
+			if !reflect.DeepEqual(cols, columns[table]) {
+				t.Errorf("%s.%s: Expected columns %v, got %v", s.Name(), table, cols, columns[table])
+			}
+
+			idxs, err := s.Indexes(db, table)
+			if err != nil {
+				t.Fatalf("Indexes returned error %s", err)
+			}
+			if got, ok := indexes[table]; len(idxs) > 0 != ok || !reflect.DeepEqual(idxs, got) {
+				t.Errorf("%s.%s: Expected indexes %v, got %v", s.Name(), table, idxs, got)
+			}
+
+			fks, err := s.ForeignKeys(db, table)
+			if err != nil {
+				t.Fatalf("ForeignKeys returned error %s", err)
+			}
+			if got, ok := foreignKeys[table]; len(fks) > 0 != ok || !reflect.DeepEqual(fks, got) {
+				t.Errorf("%s.%s: Expected foreign keys %v, got %v", s.Name(), table, fks, got)
+			}
+		}
+	}
+
+	// Spot check the results of the top-level functions.
+	columns, err := meta.AllColumns(db)
+	if err != nil {
+		t.Fatalf("AllColumns returned error %s", err)
+	}
+	if n := len(columns["users"]); n != 3 {
+		t.Errorf("Expected 3 columns for users, got %d", n)
+	}
+	if _, ok := columns["post_titles"]; ok {
+		t.Errorf("Expected views to be excluded from AllColumns")
+	}
+
+	indexes, err := meta.AllIndexes(db)
+	if err != nil {
+		t.Fatalf("AllIndexes returned error %s", err)
+	}
+	if n := len(indexes["posts"]); n != 2 {
+		t.Errorf("Expected 2 indexes for posts, got %d", n)
+	}
+	if _, ok := indexes["comments"]; ok {
+		t.Errorf("Expected no indexes for comments")
+	}
+
+	foreignKeys, err := meta.AllForeignKeys(db)
+	if err != nil {
+		t.Fatalf("AllForeignKeys returned error %s", err)
+	}
+	if n := len(foreignKeys["comments"]); n != 2 {
+		t.Errorf("Expected 2 foreign keys for comments, got %d", n)
+	}
+	if len(foreignKeys) != 2 {
+		t.Errorf("Expected foreign keys for 2 tables, got %d", len(foreignKeys))
+	}
+
+	errs := []struct {
+		Func  func() error
+		Error string
+	}{
+		{
+			Func: func() error {
+				_, err := meta.DB("xxxxx").AllColumns(db)
+				return err
+			},
+			Error: "could not get columns: unknown database 'xxxxx'",
+		},
+		{
+			Func: func() error {
+				_, err := meta.DB("xxxxx").AllIndexes(db)
+				return err
+			},
+			Error: "could not get indexes: unknown database 'xxxxx'",
+		},
+		{
+			Func: func() error {
+				_, err := meta.DB("xxxxx").AllForeignKeys(db)
+				return err
+			},
+			Error: "could not get foreign keys: unknown database 'xxxxx'",
+		},
+	}
+
+	for _, test := range errs {
+		if err := test.Func(); err == nil || err.Error() != test.Error {
+			t.Errorf("Expected error %q, got %v", test.Error, err)
+		}
+	}
+}
+
+func TestBulkMissingModule(t *testing.T) {
+	testWithDB(t, testBulkMissingModule)
+}
+
+func TestBulkMissingModuleLegacy(t *testing.T) {
+	defer meta.SetLegacyPragmas(true)()
+	testWithDB(t, testBulkMissingModule)
+}
+
+func testBulkMissingModule(t *testing.T, db *sql.DB) {
+
+	ctx := context.Background()
+
+	// SQLite only notices the edits to sqlite_master when the
+	// schema is reloaded, so run everything on one connection.
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatalf("Could not get connection: %s", err)
+	}
+	defer conn.Close()
+
+	var version int
+	if err := conn.QueryRowContext(ctx, "PRAGMA schema_version").Scan(&version); err != nil {
+		t.Fatalf("Could not get schema version: %s", err)
+	}
+
+	for _, q := range []string{
+		"DROP TABLE IF EXISTS items",
+		"CREATE TABLE items (id INTEGER PRIMARY KEY, name TEXT UNIQUE, parent_id INTEGER REFERENCES items)",
+		"PRAGMA writable_schema = ON",
+		"INSERT INTO sqlite_master VALUES ('table', 'search', 'search', 0, 'CREATE VIRTUAL TABLE search USING missing_module(name)')",
+		"PRAGMA writable_schema = OFF",
+		fmt.Sprintf("PRAGMA schema_version = %d", version+10),
+	} {
+		if _, err := conn.ExecContext(ctx, q); err != nil {
+			t.Fatalf("Exec %q returned error %s", q, err)
+		}
+	}
+
+	defer func() {
+		for _, q := range []string{
+			"PRAGMA writable_schema = ON",
+			"DELETE FROM sqlite_master WHERE name = 'search'",
+			"PRAGMA writable_schema = OFF",
+			fmt.Sprintf("PRAGMA schema_version = %d", version+20),
+		} {
+			if _, err := conn.ExecContext(ctx, q); err != nil {
+				t.Fatalf("Exec %q returned error %s", q, err)
+			}
+		}
+	}()
+
+	if _, err := meta.Columns(conn, "search"); err == nil {
+		t.Fatalf("Expected an error getting columns for search")
+	}
+
+	columns, err := meta.AllColumns(conn)
+	if err != nil {
+		t.Fatalf("AllColumns returned error %s", err)
+	}
+	if n := len(columns["items"]); n != 3 {
+		t.Errorf("Expected 3 columns for items, got %d", n)
+	}
+	if _, ok := columns["search"]; ok {
+		t.Errorf("Expected search to be excluded from AllColumns")
+	}
+
+	indexes, err := meta.AllIndexes(conn)
+	if err != nil {
+		t.Fatalf("AllIndexes returned error %s", err)
+	}
+	if n := len(indexes["items"]); n != 1 {
+		t.Errorf("Expected 1 index for items, got %d", n)
+	}
+
+	foreignKeys, err := meta.AllForeignKeys(conn)
+	if err != nil {
+		t.Fatalf("AllForeignKeys returned error %s", err)
+	}
+	if n := len(foreignKeys["items"]); n != 1 {
+		t.Errorf("Expected 1 foreign key for items, got %d", n)
+	}
+}
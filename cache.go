@@ -0,0 +1,318 @@
+package sqlitemeta
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// A Cache memoizes the results of the metadata functions in this
+// package. Cached results for a database are discarded whenever
+// its schema changes (as indicated by PRAGMA schema_version), so
+// a Cache never returns stale metadata.
+//
+// Checking the schema version is much cheaper than querying the
+// metadata itself, but it still requires a query on each call.
+// For functions that search all attached databases (e.g. the
+// Cache.Columns method), the versions of all of the databases
+// are checked.
+//
+// A Cache is safe for concurrent use by multiple goroutines.
+// The slices returned by a Cache are shared between callers and
+// must not be modified.
+//
+// Note that each connection in a database/sql connection pool
+// has its own temp database and attached databases. If temporary
+// objects or attached databases are in use, create the Cache
+// with a *sql.Conn to get consistent results.
+type Cache struct {
+	db      Querier
+	mu      sync.Mutex
+	schemas map[string]*schemaEntries
+}
+
+// schemaEntries holds the cached results for a Schema.
+type schemaEntries struct {
+	version string
+	values  map[cacheKey]interface{}
+}
+
+type cacheKey struct {
+	kind string
+	name string
+}
+
+// NewCache returns a Cache for the given database handle or
+// connection.
+func NewCache(db Querier) *Cache {
+	return &Cache{
+		db:      db,
+		schemas: map[string]*schemaEntries{},
+	}
+}
+
+// Schema returns a SchemaCache that caches metadata for the
+// given Schema.
+func (c *Cache) Schema(s *Schema) *SchemaCache {
+	return &SchemaCache{c, s}
+}
+
+// Clear discards all of the cached results.
+func (c *Cache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.schemas = map[string]*schemaEntries{}
+}
+
+// TableNames is the cached equivalent of the TableNames function.
+func (c *Cache) TableNames(opts ...ListOption) ([]string, error) {
+	return c.Schema(noSchema).TableNames(opts...)
+}
+
+// ViewNames is the cached equivalent of the ViewNames function.
+func (c *Cache) ViewNames(opts ...ListOption) ([]string, error) {
+	return c.Schema(noSchema).ViewNames(opts...)
+}
+
+// TriggerNames is the cached equivalent of the TriggerNames
+// function.
+func (c *Cache) TriggerNames(opts ...ListOption) ([]string, error) {
+	return c.Schema(noSchema).TriggerNames(opts...)
+}
+
+// IndexNames is the cached equivalent of the IndexNames function.
+func (c *Cache) IndexNames(opts ...ListOption) ([]string, error) {
+	return c.Schema(noSchema).IndexNames(opts...)
+}
+
+// Columns is the cached equivalent of the Columns function.
+func (c *Cache) Columns(tableName string) ([]Column, error) {
+	return c.Schema(noSchema).Columns(tableName)
+}
+
+// ForeignKeys is the cached equivalent of the ForeignKeys
+// function.
+func (c *Cache) ForeignKeys(tableName string) ([]ForeignKey, error) {
+	return c.Schema(noSchema).ForeignKeys(tableName)
+}
+
+// Indexes is the cached equivalent of the Indexes function.
+func (c *Cache) Indexes(tableName string) ([]Index, error) {
+	return c.Schema(noSchema).Indexes(tableName)
+}
+
+// IndexColumns is the cached equivalent of the IndexColumns
+// function.
+func (c *Cache) IndexColumns(indexName string) ([]IndexColumn, error) {
+	return c.Schema(noSchema).IndexColumns(indexName)
+}
+
+// IndexColumnsAux is the cached equivalent of the
+// IndexColumnsAux function.
+func (c *Cache) IndexColumnsAux(indexName string) ([]IndexColumn, error) {
+	return c.Schema(noSchema).IndexColumnsAux(indexName)
+}
+
+// A SchemaCache caches the metadata for a Schema. It is created
+// by the Cache.Schema method.
+type SchemaCache struct {
+	c *Cache
+	s *Schema
+}
+
+// TableNames is the cached equivalent of Schema.TableNames.
+func (sc *SchemaCache) TableNames(opts ...ListOption) ([]string, error) {
+	return sc.names("table", sc.s.TableNames, opts)
+}
+
+// ViewNames is the cached equivalent of Schema.ViewNames.
+func (sc *SchemaCache) ViewNames(opts ...ListOption) ([]string, error) {
+	return sc.names("view", sc.s.ViewNames, opts)
+}
+
+// TriggerNames is the cached equivalent of Schema.TriggerNames.
+func (sc *SchemaCache) TriggerNames(opts ...ListOption) ([]string, error) {
+	return sc.names("trigger", sc.s.TriggerNames, opts)
+}
+
+// IndexNames is the cached equivalent of Schema.IndexNames.
+func (sc *SchemaCache) IndexNames(opts ...ListOption) ([]string, error) {
+	return sc.names("index", sc.s.IndexNames, opts)
+}
+
+func (sc *SchemaCache) names(typ string, fn func(Querier, ...ListOption) ([]string, error), opts []ListOption) ([]string, error) {
+
+	// The options are part of the key's kind rather than its
+	// name because names are case-insensitive but patterns
+	// aren't.
+	kind := typ + " names " + newListOptions(opts).key()
+
+	v, err := sc.get(cacheKey{kind, ""}, func() (interface{}, error) {
+		return fn(sc.c.db, opts...)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return v.([]string), nil
+}
+
+// Columns is the cached equivalent of Schema.Columns.
+func (sc *SchemaCache) Columns(tableName string) ([]Column, error) {
+
+	v, err := sc.get(cacheKey{"columns", tableName}, func() (interface{}, error) {
+		return sc.s.Columns(sc.c.db, tableName)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return v.([]Column), nil
+}
+
+// ForeignKeys is the cached equivalent of Schema.ForeignKeys.
+func (sc *SchemaCache) ForeignKeys(tableName string) ([]ForeignKey, error) {
+
+	v, err := sc.get(cacheKey{"foreign keys", tableName}, func() (interface{}, error) {
+		return sc.s.ForeignKeys(sc.c.db, tableName)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return v.([]ForeignKey), nil
+}
+
+// Indexes is the cached equivalent of Schema.Indexes.
+func (sc *SchemaCache) Indexes(tableName string) ([]Index, error) {
+
+	v, err := sc.get(cacheKey{"indexes", tableName}, func() (interface{}, error) {
+		return sc.s.Indexes(sc.c.db, tableName)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return v.([]Index), nil
+}
+
+// IndexColumns is the cached equivalent of Schema.IndexColumns.
+func (sc *SchemaCache) IndexColumns(indexName string) ([]IndexColumn, error) {
+
+	v, err := sc.get(cacheKey{"index columns", indexName}, func() (interface{}, error) {
+		return sc.s.IndexColumns(sc.c.db, indexName)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return v.([]IndexColumn), nil
+}
+
+// IndexColumnsAux is the cached equivalent of
+// Schema.IndexColumnsAux.
+func (sc *SchemaCache) IndexColumnsAux(indexName string) ([]IndexColumn, error) {
+
+	v, err := sc.get(cacheKey{"index columns aux", indexName}, func() (interface{}, error) {
+		return sc.s.IndexColumnsAux(sc.c.db, indexName)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return v.([]IndexColumn), nil
+}
+
+// get returns the cached value for the given key, calling load
+// to fetch the value if it isn't cached or if the schema has
+// changed since it was cached. Errors are not cached.
+func (sc *SchemaCache) get(key cacheKey, load func() (interface{}, error)) (interface{}, error) {
+
+	key.name = sqlower(key.name)
+	schema := sqlower(sc.s.name)
+
+	version, err := sc.version()
+	if err != nil {
+		// Let the uncached function report the error (e.g. an
+		// unknown database) in the usual way.
+		return load()
+	}
+
+	sc.c.mu.Lock()
+	entries := sc.c.schemas[schema]
+	if entries != nil && entries.version == version {
+		if v, ok := entries.values[key]; ok {
+			sc.c.mu.Unlock()
+			return v, nil
+		}
+	}
+	sc.c.mu.Unlock()
+
+	v, err := load()
+	if err != nil {
+		return nil, err
+	}
+
+	sc.c.mu.Lock()
+	defer sc.c.mu.Unlock()
+
+	entries = sc.c.schemas[schema]
+	if entries == nil || entries.version != version {
+		entries = &schemaEntries{
+			version: version,
+			values:  map[cacheKey]interface{}{},
+		}
+		sc.c.schemas[schema] = entries
+	}
+
+	entries.values[key] = v
+
+	return v, nil
+}
+
+// version returns a value that changes whenever the schema of
+// the cached database changes. If the Schema has no name, the
+// value reflects the schemas of all attached databases.
+func (sc *SchemaCache) version() (string, error) {
+
+	if sc.s.name != "" {
+		v, err := schemaVersion(sc.c.db, sc.s.name)
+		return fmt.Sprint(v), err
+	}
+
+	databases, err := databaseList(sc.c.db)
+	if err != nil {
+		return "", err
+	}
+
+	var versions []string
+
+	for _, d := range databases {
+
+		v, err := schemaVersion(sc.c.db, d.Name)
+		if err != nil {
+			return "", err
+		}
+
+		versions = append(versions, fmt.Sprintf("%s:%d", d.Name, v))
+	}
+
+	return strings.Join(versions, ","), nil
+}
+
+func schemaVersion(db Querier, schemaName string) (int64, error) {
+
+	var version int64
+
+	// The schema name is quoted so it's safe to insert into the
+	// SQL. If it's not a valid database, the query will fail.
+	q := fmt.Sprintf("PRAGMA %s.schema_version", quoteIdent(schemaName))
+
+	err := db.QueryRowContext(context.Background(), q).Scan(&version)
+	if err != nil {
+		return 0, err
+	}
+
+	return version, nil
+}
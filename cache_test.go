@@ -0,0 +1,135 @@
+package sqlitemeta_test
+
+import (
+	"database/sql"
+	"fmt"
+	"sync"
+	"testing"
+
+	meta "github.com/deepilla/sqlitemeta"
+)
+
+func TestCache(t *testing.T) {
+	testWithDB(t, testCache)
+}
+
+func testCache(t *testing.T, db *sql.DB) {
+
+	exec(t, db, []string{
+		"DROP TABLE IF EXISTS users",
+		"CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT)",
+		"ATTACH DATABASE ':memory:' AS aux",
+		"CREATE TABLE aux.logs (msg TEXT)",
+	})
+
+	cache := meta.NewCache(db)
+
+	columnNames := func(columns []meta.Column) []string {
+		var names []string
+		for _, c := range columns {
+			names = append(names, c.Name)
+		}
+		return names
+	}
+
+	c1, err := cache.Columns("users")
+	if err != nil {
+		t.Fatalf("Columns returned error %s", err)
+	}
+
+	c2, err := cache.Columns("USERS")
+	if err != nil {
+		t.Fatalf("Columns returned error %s", err)
+	}
+
+	if exp := []string{"id", "name"}; !equalStringSlices(exp, columnNames(c2)) {
+		t.Errorf("Expected columns %v, got %v", exp, columnNames(c2))
+	}
+
+	// Cached results share the same backing array.
+	if &c1[0] != &c2[0] {
+		t.Errorf("Expected second call to Columns to return cached results")
+	}
+
+	// Schema changes invalidate the cache.
+	exec(t, db, []string{
+		"ALTER TABLE users ADD COLUMN email TEXT",
+	})
+
+	c3, err := cache.Columns("users")
+	if err != nil {
+		t.Fatalf("Columns returned error %s", err)
+	}
+
+	if exp := []string{"id", "name", "email"}; !equalStringSlices(exp, columnNames(c3)) {
+		t.Errorf("Expected columns %v after ALTER TABLE, got %v", exp, columnNames(c3))
+	}
+
+	// Changes to one schema don't affect the cache for another.
+	aux := cache.Schema(meta.DB("aux"))
+
+	l1, err := aux.TableNames()
+	if err != nil {
+		t.Fatalf("TableNames returned error %s", err)
+	}
+
+	exec(t, db, []string{
+		"CREATE TABLE main.other (x)",
+	})
+
+	l2, err := aux.TableNames()
+	if err != nil {
+		t.Fatalf("TableNames returned error %s", err)
+	}
+
+	if exp := []string{"logs"}; !equalStringSlices(exp, l2) {
+		t.Errorf("Expected aux tables %v, got %v", exp, l2)
+	}
+	if &l1[0] != &l2[0] {
+		t.Errorf("Expected aux table names to be cached")
+	}
+
+	exec(t, db, []string{
+		"CREATE TABLE aux.more (x)",
+	})
+
+	l3, err := aux.TableNames()
+	if err != nil {
+		t.Fatalf("TableNames returned error %s", err)
+	}
+
+	if exp := []string{"logs", "more"}; !equalStringSlices(exp, l3) {
+		t.Errorf("Expected aux tables %v, got %v", exp, l3)
+	}
+
+	// Errors are the same as for the uncached functions.
+	_, exp := meta.DB("xxxxx").Indexes(db, "users")
+	_, got := cache.Schema(meta.DB("xxxxx")).Indexes("users")
+	if !equalErrors(exp, got) {
+		t.Errorf("Expected error %v, got %v", exp, got)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			indexes, err := cache.Indexes("users")
+			if err == nil && len(indexes) != 0 {
+				err = fmt.Errorf("expected no indexes, got %d", len(indexes))
+			}
+			if err != nil {
+				errs <- err
+			}
+		}(i)
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Errorf("Concurrent Indexes call failed: %s", err)
+	}
+}
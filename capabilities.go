@@ -0,0 +1,143 @@
+package sqlitemeta
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// CapabilityInfo describes the version and features of the
+// SQLite library used by a database connection.
+type CapabilityInfo struct {
+	Version       string // e.g. "3.46.1"
+	VersionNumber int    // e.g. 3046001
+
+	// CompileOptions lists the options that SQLite was compiled
+	// with, without the "SQLITE_" prefix, e.g. "ENABLE_FTS5" or
+	// "THREADSAFE=1".
+	CompileOptions []string
+
+	// PragmaFunctions is true if PRAGMAs can be queried as
+	// table-valued functions, e.g. pragma_table_info (SQLite
+	// 3.16.0 and later). If false, this package falls back to
+	// running PRAGMA statements directly.
+	PragmaFunctions bool
+
+	WithoutRowID     bool // WITHOUT ROWID tables (3.8.2).
+	HiddenColumns    bool // PRAGMA table_xinfo (3.26.0).
+	GeneratedColumns bool // Generated columns (3.31.0).
+	TableList        bool // PRAGMA table_list (3.37.0).
+	Strict           bool // STRICT tables (3.37.0).
+
+	JSON   bool // The JSON functions.
+	FTS3   bool // The FTS3 and FTS4 full-text search modules.
+	FTS5   bool // The FTS5 full-text search module.
+	RTree  bool // The R*Tree module.
+	DBStat bool // The dbstat virtual table.
+	Stat4  bool // sqlite_stat4 statistics.
+}
+
+// HasCompileOption reports whether SQLite was compiled with the
+// given option. The "SQLITE_" prefix is optional. Options with
+// values (e.g. "THREADSAFE=1") match on the name alone.
+func (c *CapabilityInfo) HasCompileOption(name string) bool {
+
+	name = strings.TrimPrefix(strings.ToUpper(name), "SQLITE_")
+
+	for _, opt := range c.CompileOptions {
+		if opt = strings.ToUpper(opt); opt == name || strings.HasPrefix(opt, name+"=") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Capabilities returns the version and features of the SQLite
+// library used by a database connection.
+func Capabilities(db Querier) (*CapabilityInfo, error) {
+
+	c := &CapabilityInfo{}
+
+	err := db.QueryRowContext(context.Background(), "SELECT sqlite_version()").Scan(&c.Version)
+	if err != nil {
+		return nil, fmt.Errorf("could not get capabilities: %s", err)
+	}
+
+	c.VersionNumber = versionNumber(c.Version)
+
+	c.CompileOptions, err = queryStrings(db, "PRAGMA compile_options")
+	if err != nil {
+		return nil, fmt.Errorf("could not get capabilities: %s", err)
+	}
+
+	c.PragmaFunctions = c.VersionNumber >= 3016000
+	c.WithoutRowID = c.VersionNumber >= 3008002
+	c.HiddenColumns = c.VersionNumber >= 3026000
+	c.GeneratedColumns = c.VersionNumber >= 3031000
+	c.TableList = c.VersionNumber >= 3037000
+	c.Strict = c.VersionNumber >= 3037000
+
+	c.JSON = c.HasCompileOption("ENABLE_JSON1") || c.VersionNumber >= 3038000 && !c.HasCompileOption("OMIT_JSON")
+	c.FTS3 = c.HasCompileOption("ENABLE_FTS3") || c.HasCompileOption("ENABLE_FTS4")
+	c.FTS5 = c.HasCompileOption("ENABLE_FTS5")
+	c.RTree = c.HasCompileOption("ENABLE_RTREE")
+	c.DBStat = c.HasCompileOption("ENABLE_DBSTAT_VTAB")
+	c.Stat4 = c.HasCompileOption("ENABLE_STAT4") || c.HasCompileOption("ENABLE_STAT3")
+
+	return c, nil
+}
+
+// versionNumber converts an SQLite version string into the
+// equivalent integer, e.g. "3.16.2" becomes 3016002.
+func versionNumber(version string) int {
+
+	n := 0
+	parts := strings.SplitN(version, ".", 3)
+
+	for i := 0; i < 3; i++ {
+		n *= 1000
+		if i < len(parts) {
+			v, _ := strconv.Atoi(parts[i])
+			n += v
+		}
+	}
+
+	return n
+}
+
+// legacyPragmas forces the use of PRAGMA statements instead of
+// table-valued pragma functions. It is used for testing.
+var legacyPragmas = false
+
+// queryPragma runs a query that uses table-valued pragma
+// functions. If the query fails because the SQLite library
+// does not support pragma functions, the legacy function is
+// run instead. Legacy receives a prefix for PRAGMA statements
+// that qualifies them with the name of this Schema, e.g.
+// `"aux".`.
+func (s *Schema) queryPragma(db Querier, query func() error, legacy func(prefix string) error) error {
+
+	if !legacyPragmas {
+
+		err := query()
+		if err == nil {
+			return nil
+		}
+
+		c, cerr := Capabilities(db)
+		if cerr != nil || c.PragmaFunctions {
+			return err
+		}
+	}
+
+	// The schema name is inserted directly into the SQL so
+	// qualify checks that it's valid.
+	prefix, err := s.qualify(db, "")
+	if err != nil {
+		return err
+	}
+
+	return legacy(prefix)
+}
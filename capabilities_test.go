@@ -0,0 +1,85 @@
+package sqlitemeta_test
+
+import (
+	"database/sql"
+	"testing"
+
+	meta "github.com/deepilla/sqlitemeta"
+)
+
+func TestCapabilities(t *testing.T) {
+	testWithDB(t, testCapabilities)
+}
+
+func testCapabilities(t *testing.T, db *sql.DB) {
+
+	var version string
+	if err := db.QueryRow("SELECT sqlite_version()").Scan(&version); err != nil {
+		t.Fatalf("Could not get SQLite version: %s", err)
+	}
+
+	c, err := meta.Capabilities(db)
+	if err != nil {
+		t.Fatalf("Capabilities returned error %s", err)
+	}
+
+	if c.Version != version {
+		t.Errorf("Expected version %s, got %s", version, c.Version)
+	}
+
+	// The package's tests require pragma functions, so this
+	// is at least 3.16.0.
+	if c.VersionNumber < 3016000 || !c.PragmaFunctions || !c.WithoutRowID {
+		t.Errorf("Expected version 3.16.0 or later with pragma functions, got %d", c.VersionNumber)
+	}
+
+	if len(c.CompileOptions) == 0 {
+		t.Errorf("Expected compile options, got none")
+	}
+
+	for _, opt := range c.CompileOptions {
+		if !c.HasCompileOption(opt) || !c.HasCompileOption("SQLITE_"+opt) {
+			t.Errorf("Expected HasCompileOption(%q) to return true", opt)
+		}
+	}
+
+	if c.HasCompileOption("XXXXX") {
+		t.Errorf("Expected HasCompileOption(%q) to return false", "XXXXX")
+	}
+
+	// The R*Tree module is enabled by default in go-sqlite3.
+	if !c.RTree {
+		t.Errorf("Expected R*Tree support")
+	}
+
+	if _, err := db.Exec("CREATE VIRTUAL TABLE temp.fts USING fts5(x)"); (err == nil) != c.FTS5 {
+		t.Errorf("Expected FTS5 support to be %t, got error %v", c.FTS5, err)
+	}
+}
+
+// TestLegacyPragmas runs the metadata tests using PRAGMA
+// statements instead of pragma functions.
+func TestLegacyPragmas(t *testing.T) {
+
+	defer meta.SetLegacyPragmas(true)()
+
+	tests := []struct {
+		Name string
+		Func func(*testing.T, *sql.DB)
+	}{
+		{"SchemaNames", testSchemaNames},
+		{"Columns", testColumns},
+		{"ForeignKeys", testForeignKeys},
+		{"Indexes", testIndexes},
+		{"IndexColumns", testIndexColumns},
+		{"BadSchema", testBadSchema},
+		{"BadTarget", testBadTarget},
+		{"Info", testInfo},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			testWithDB(t, test.Func)
+		})
+	}
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"database/sql"
+
+	meta "github.com/deepilla/sqlitemeta"
+)
+
+func runSchemas(db *sql.DB, s *meta.Schema, arg string, opts *options) (*result, error) {
+
+	names, err := meta.SchemaNames(db)
+	if err != nil {
+		return nil, err
+	}
+
+	return namesResult(names), nil
+}
+
+func namesCommand(fn func(meta.Querier, ...meta.ListOption) ([]string, error), method func(*meta.Schema, meta.Querier, ...meta.ListOption) ([]string, error)) func(*sql.DB, *meta.Schema, string, *options) (*result, error) {
+	return func(db *sql.DB, s *meta.Schema, arg string, opts *options) (*result, error) {
+
+		var listOpts []meta.ListOption
+		if opts.NoInternal {
+			listOpts = append(listOpts, meta.ExcludeInternal())
+		}
+		if opts.Match != "" {
+			listOpts = append(listOpts, meta.MatchGlob(opts.Match))
+		}
+
+		var names []string
+		var err error
+
+		if s != nil {
+			names, err = method(s, db, listOpts...)
+		} else {
+			names, err = fn(db, listOpts...)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		return namesResult(names), nil
+	}
+}
+
+func namesResult(names []string) *result {
+
+	res := &result{
+		Fields: []string{"name"},
+	}
+
+	for _, name := range names {
+		res.Rows = append(res.Rows, []interface{}{name})
+	}
+
+	return res
+}
+
+func runColumns(db *sql.DB, s *meta.Schema, table string, opts *options) (*result, error) {
+
+	var columns []meta.Column
+	var err error
+
+	if s != nil {
+		columns, err = s.Columns(db, table)
+	} else {
+		columns, err = meta.Columns(db, table)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res := &result{
+		Fields: []string{"id", "name", "type", "notnull", "default", "pk"},
+	}
+
+	for _, c := range columns {
+
+		var dflt interface{}
+		if c.Default != nil {
+			dflt = string(c.Default)
+		}
+
+		res.Rows = append(res.Rows, []interface{}{c.ID, c.Name, c.Type, c.NotNull, dflt, c.PrimaryKey})
+	}
+
+	return res, nil
+}
+
+func runIndexes(db *sql.DB, s *meta.Schema, table string, opts *options) (*result, error) {
+
+	var indexes []meta.Index
+	var err error
+
+	if s != nil {
+		indexes, err = s.Indexes(db, table)
+	} else {
+		indexes, err = meta.Indexes(db, table)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res := &result{
+		Fields: []string{"name", "type", "unique", "partial", "columns"},
+	}
+
+	for _, idx := range indexes {
+
+		var names []string
+		for _, name := range idx.ColumnNames {
+			names = append(names, nullString(name, "<expr>"))
+		}
+
+		res.Rows = append(res.Rows, []interface{}{idx.Name, idx.Type.String(), idx.IsUnique, idx.IsPartial, names})
+	}
+
+	return res, nil
+}
+
+func runIndexColumns(db *sql.DB, s *meta.Schema, index string, opts *options) (*result, error) {
+
+	fn, method := meta.IndexColumns, (*meta.Schema).IndexColumns
+	if opts.Aux {
+		fn, method = meta.IndexColumnsAux, (*meta.Schema).IndexColumnsAux
+	}
+
+	var columns []meta.IndexColumn
+	var err error
+
+	if s != nil {
+		columns, err = method(s, db, index)
+	} else {
+		columns, err = fn(db, index)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res := &result{
+		Fields: []string{"rank", "name", "table_rank", "desc", "collation", "key"},
+	}
+
+	for _, c := range columns {
+
+		var name interface{}
+		if c.Name.Valid {
+			name = c.Name.String
+		}
+
+		res.Rows = append(res.Rows, []interface{}{c.Rank, name, c.TableRank, c.Descending, c.Collation, c.IsKey})
+	}
+
+	return res, nil
+}
+
+func runForeignKeys(db *sql.DB, s *meta.Schema, table string, opts *options) (*result, error) {
+
+	var foreignKeys []meta.ForeignKey
+	var err error
+
+	if s != nil {
+		foreignKeys, err = s.ForeignKeys(db, table)
+	} else {
+		foreignKeys, err = meta.ForeignKeys(db, table)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res := &result{
+		Fields: []string{"id", "child_key", "parent_table", "parent_key", "on_update", "on_delete"},
+	}
+
+	for _, fk := range foreignKeys {
+
+		var parentKey []string
+		for _, name := range fk.ParentKey {
+			parentKey = append(parentKey, nullString(name, ""))
+		}
+
+		res.Rows = append(res.Rows, []interface{}{fk.ID, fk.ChildKey, fk.ParentTable, parentKey, fk.OnUpdate.String(), fk.OnDelete.String()})
+	}
+
+	return res, nil
+}
+
+func nullString(s sql.NullString, null string) string {
+	if !s.Valid {
+		return null
+	}
+	return s.String
+}
@@ -0,0 +1,257 @@
+// Command sqlitemeta prints metadata about an SQLite database.
+//
+// Usage:
+//
+//     sqlitemeta <command> [flags] <database> [name]
+//
+// The database is opened read-only and must already exist.
+//
+// The commands mirror the functions in the sqlitemeta package:
+//
+//     schemas          list attached databases
+//     tables           list tables
+//     views            list views
+//     triggers         list triggers
+//     indexes          list the indexes on a table
+//     columns          list the columns in a table
+//     index-columns    list the columns in an index
+//     fks              list the foreign keys on a table
+//     structs          generate Go structs for tables
+//
+// The columns, indexes and fks commands take a table name and
+// the index-columns command takes an index name. The structs
+// command takes an optional list of table names and is intended
+// for use with go generate, e.g.
+//
+//     //go:generate sqlitemeta structs -package models -o models.go app.db
+//
+// Flags:
+//
+//     -schema NAME     restrict the command to the named database
+//     -format FORMAT   output format: table (default), json or csv
+//     -aux             include auxiliary index columns (index-columns only)
+//     -no-internal     exclude internal and shadow tables (tables, views and triggers only)
+//     -match PATTERN   only list names that match a GLOB pattern (tables, views and triggers only)
+package main
+
+import (
+	"database/sql"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	meta "github.com/deepilla/sqlitemeta"
+)
+
+// A command is a sqlitemeta subcommand.
+type command struct {
+	Summary string
+	Arg     string // The name of the command's argument, if any.
+	Run     func(db *sql.DB, s *meta.Schema, arg string, flags *options) (*result, error)
+
+	// Main, if set, handles the command in place of Run. It
+	// is responsible for parsing its own flags.
+	Main func(args []string, stdout, stderr io.Writer) int
+}
+
+type options struct {
+	Schema string
+	Format string
+	Aux    bool
+
+	NoInternal bool   // Exclude internal tables (tables, views and triggers only).
+	Match      string // Filter names by GLOB pattern (tables, views and triggers only).
+}
+
+var commands = map[string]command{
+	"schemas": {
+		Summary: "list attached databases",
+		Run:     runSchemas,
+	},
+	"tables": {
+		Summary: "list tables",
+		Run:     namesCommand(meta.TableNames, (*meta.Schema).TableNames),
+	},
+	"views": {
+		Summary: "list views",
+		Run:     namesCommand(meta.ViewNames, (*meta.Schema).ViewNames),
+	},
+	"triggers": {
+		Summary: "list triggers",
+		Run:     namesCommand(meta.TriggerNames, (*meta.Schema).TriggerNames),
+	},
+	"indexes": {
+		Summary: "list the indexes on a table",
+		Arg:     "table",
+		Run:     runIndexes,
+	},
+	"columns": {
+		Summary: "list the columns in a table",
+		Arg:     "table",
+		Run:     runColumns,
+	},
+	"index-columns": {
+		Summary: "list the columns in an index",
+		Arg:     "index",
+		Run:     runIndexColumns,
+	},
+	"fks": {
+		Summary: "list the foreign keys on a table",
+		Arg:     "table",
+		Run:     runForeignKeys,
+	},
+	"structs": {
+		Summary: "generate Go structs for tables",
+		Arg:     "table...",
+		Main:    runStructs,
+	},
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+
+	if len(args) == 0 {
+		usage(stderr)
+		return 2
+	}
+
+	name := args[0]
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(stderr, "sqlitemeta: unknown command %q\n\n", name)
+		usage(stderr)
+		return 2
+	}
+
+	if cmd.Main != nil {
+		return cmd.Main(args[1:], stdout, stderr)
+	}
+
+	var opts options
+
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.StringVar(&opts.Schema, "schema", "", "restrict the command to the named database")
+	fs.StringVar(&opts.Format, "format", "table", "output format: table, json or csv")
+	if name == "index-columns" {
+		fs.BoolVar(&opts.Aux, "aux", false, "include auxiliary index columns")
+	}
+	if name == "tables" || name == "views" || name == "triggers" {
+		fs.BoolVar(&opts.NoInternal, "no-internal", false, "exclude internal and shadow tables")
+		fs.StringVar(&opts.Match, "match", "", "only list names that match the given GLOB pattern")
+	}
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: sqlitemeta %s [flags] <database>%s\n\nFlags:\n", name, argUsage(cmd))
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+
+	nargs := 1
+	if cmd.Arg != "" {
+		nargs = 2
+	}
+
+	if fs.NArg() != nargs {
+		fs.Usage()
+		return 2
+	}
+
+	write, ok := writers[opts.Format]
+	if !ok {
+		fmt.Fprintf(stderr, "sqlitemeta: unknown format %q\n", opts.Format)
+		return 2
+	}
+
+	db, err := openDB(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(stderr, "sqlitemeta: %s\n", err)
+		return 1
+	}
+	defer db.Close()
+
+	var s *meta.Schema
+	if opts.Schema != "" {
+		s = meta.DB(opts.Schema)
+	}
+
+	res, err := cmd.Run(db, s, fs.Arg(1), &opts)
+	if err != nil {
+		fmt.Fprintf(stderr, "sqlitemeta: %s\n", err)
+		return 1
+	}
+
+	if err := write(stdout, res); err != nil {
+		fmt.Fprintf(stderr, "sqlitemeta: %s\n", err)
+		return 1
+	}
+
+	return 0
+}
+
+func usage(w io.Writer) {
+
+	fmt.Fprintf(w, "Usage: sqlitemeta <command> [flags] <database> [name]\n\nCommands:\n")
+
+	var names []string
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		cmd := commands[name]
+		fmt.Fprintf(w, "    %-22s %s\n", name+argUsage(cmd), cmd.Summary)
+	}
+
+	fmt.Fprintf(w, "\nRun 'sqlitemeta <command> -h' for command flags.\n")
+}
+
+func argUsage(cmd command) string {
+	if cmd.Arg == "" {
+		return ""
+	}
+	return " <" + cmd.Arg + ">"
+}
+
+var uriEscaper = strings.NewReplacer("%", "%25", "?", "%3f", "#", "%23")
+
+func openDB(filename string) (*sql.DB, error) {
+
+	// Check that the file exists. Otherwise the driver will
+	// happily create a new, empty database.
+	if _, err := os.Stat(filename); err != nil {
+		return nil, err
+	}
+
+	// Open the database read-only, using a URI filename. The
+	// characters that have a special meaning in URIs must be
+	// escaped.
+	uri := "file:" + uriEscaper.Replace(filename) + "?mode=ro"
+
+	db, err := sql.Open("sqlite3", uri)
+	if err != nil {
+		return nil, err
+	}
+
+	// Attached databases and temp tables are per-connection
+	// so use a single connection throughout.
+	db.SetMaxOpenConns(1)
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+
+	f, err := ioutil.TempFile("", "sqlitemeta-cmd")
+	if err != nil {
+		t.Fatalf("Could not create temp file: %s", err)
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+
+	db, err := sql.Open("sqlite3", f.Name())
+	if err != nil {
+		t.Fatalf("Could not open db: %s", err)
+	}
+
+	for _, q := range []string{
+		"CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT NOT NULL DEFAULT 'anon')",
+		"CREATE TABLE posts (id INTEGER PRIMARY KEY, user_id REFERENCES users ON DELETE CASCADE, title)",
+		"CREATE INDEX idx_posts ON posts (title, user_id DESC)",
+	} {
+		if _, err := db.Exec(q); err != nil {
+			t.Fatalf("db.Exec %q returned error %s", q, err)
+		}
+	}
+	db.Close()
+
+	data := []struct {
+		Args   []string
+		Status int
+		Output string
+	}{
+		{
+			Args:   []string{"tables", f.Name()},
+			Output: "name\nposts\nusers\n",
+		},
+		{
+			Args:   []string{"tables", "-format", "csv", "-schema", "main", f.Name()},
+			Output: "name\nposts\nusers\n",
+		},
+		{
+			Args:   []string{"tables", "-no-internal", "-match", "u*", f.Name()},
+			Output: "name\nusers\n",
+		},
+		{
+			Args: []string{"columns", "-format", "json", f.Name(), "users"},
+			Output: `[
+  {
+    "id": 0,
+    "name": "id",
+    "type": "INTEGER",
+    "notnull": false,
+    "default": null,
+    "pk": 1
+  },
+  {
+    "id": 1,
+    "name": "name",
+    "type": "TEXT",
+    "notnull": true,
+    "default": "'anon'",
+    "pk": 0
+  }
+]
+`,
+		},
+		{
+			Args:   []string{"indexes", "-format", "csv", f.Name(), "posts"},
+			Output: "name,type,unique,partial,columns\nidx_posts,user,false,false,\"title,user_id\"\n",
+		},
+		{
+			Args: []string{"index-columns", "-aux", f.Name(), "idx_posts"},
+			Output: "rank  name     table_rank  desc   collation  key\n" +
+				"0     title    2           false  BINARY     true\n" +
+				"1     user_id  1           true   BINARY     true\n" +
+				"2     NULL     -1          false  BINARY     false\n",
+		},
+		{
+			Args:   []string{"fks", "-format", "csv", f.Name(), "posts"},
+			Output: "id,child_key,parent_table,parent_key,on_update,on_delete\n0,user_id,users,,NO ACTION,CASCADE\n",
+		},
+		{
+			Args:   []string{"views", "-format", "json", f.Name()},
+			Output: "[]\n",
+		},
+		{
+			Args:   []string{"tables", "-schema", "aux", f.Name()},
+			Status: 1,
+		},
+		{
+			Args:   []string{"tables", f.Name() + ".missing"},
+			Status: 1,
+		},
+		{
+			Args:   []string{"columns", f.Name()},
+			Status: 2,
+		},
+		{
+			Args:   []string{"tables", "-format", "xml", f.Name()},
+			Status: 2,
+		},
+		{
+			Args:   []string{"unknown"},
+			Status: 2,
+		},
+	}
+
+	for _, test := range data {
+
+		var stdout, stderr bytes.Buffer
+
+		status := run(test.Args, &stdout, &stderr)
+		if status != test.Status {
+			t.Errorf("%v: Expected status %d, got %d (stderr %q)", test.Args, test.Status, status, stderr.String())
+		}
+
+		if got := stdout.String(); got != test.Output {
+			t.Errorf("%v: Expected output\n%s\ngot\n%s", test.Args, test.Output, got)
+		}
+	}
+}
+
+func TestOpenDB(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "sqlitemeta-cmd")
+	if err != nil {
+		t.Fatalf("Could not create temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	created := filepath.Join(dir, "test.db")
+
+	db, err := sql.Open("sqlite3", created)
+	if err != nil {
+		t.Fatalf("Could not open db: %s", err)
+	}
+	if _, err := db.Exec("CREATE TABLE test (x)"); err != nil {
+		t.Fatalf("Could not create table: %s", err)
+	}
+	db.Close()
+
+	// Characters with a special meaning in URIs should be
+	// treated as part of the filename.
+	filename := filepath.Join(dir, "test?#%.db")
+	if err := os.Rename(created, filename); err != nil {
+		t.Fatalf("Could not rename db: %s", err)
+	}
+
+	db, err = openDB(filename)
+	if err != nil {
+		t.Fatalf("openDB returned error %s", err)
+	}
+	defer db.Close()
+
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM test").Scan(&count); err != nil {
+		t.Errorf("Could not query table: %s", err)
+	}
+
+	// The database should be read-only.
+	if _, err := db.Exec("INSERT INTO test VALUES (1)"); err == nil {
+		t.Errorf("Expected an error writing to the database")
+	}
+
+	if _, err := openDB(filepath.Join(dir, "missing.db")); err == nil {
+		t.Errorf("Expected an error opening a missing file")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "missing.db")); !os.IsNotExist(err) {
+		t.Errorf("Expected missing file not to be created, got %v", err)
+	}
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// A result is the output of a command: a list of rows, each
+// containing one value per field. Values may be strings, ints,
+// bools, string slices or nil (for SQL NULL).
+type result struct {
+	Fields []string
+	Rows   [][]interface{}
+}
+
+var writers = map[string]func(io.Writer, *result) error{
+	"table": writeTable,
+	"json":  writeJSON,
+	"csv":   writeCSV,
+}
+
+func writeTable(w io.Writer, res *result) error {
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+
+	for i, f := range res.Fields {
+		if i > 0 {
+			fmt.Fprint(tw, "\t")
+		}
+		fmt.Fprint(tw, f)
+	}
+	fmt.Fprintln(tw)
+
+	for _, row := range res.Rows {
+		for i, v := range row {
+			if i > 0 {
+				fmt.Fprint(tw, "\t")
+			}
+			fmt.Fprint(tw, formatValue(v, "NULL"))
+		}
+		fmt.Fprintln(tw)
+	}
+
+	return tw.Flush()
+}
+
+func writeCSV(w io.Writer, res *result) error {
+
+	cw := csv.NewWriter(w)
+
+	if err := cw.Write(res.Fields); err != nil {
+		return err
+	}
+
+	for _, row := range res.Rows {
+
+		record := make([]string, len(row))
+		for i, v := range row {
+			record[i] = formatValue(v, "")
+		}
+
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+func writeJSON(w io.Writer, res *result) error {
+
+	objects := make([]object, len(res.Rows))
+	for i, row := range res.Rows {
+		objects[i] = object{res.Fields, row}
+	}
+
+	data, err := json.MarshalIndent(objects, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "%s\n", data)
+	return err
+}
+
+// An object is a JSON object whose keys are written in a
+// fixed order.
+type object struct {
+	keys   []string
+	values []interface{}
+}
+
+func (o object) MarshalJSON() ([]byte, error) {
+
+	var buf bytes.Buffer
+
+	buf.WriteString("{")
+
+	for i, k := range o.keys {
+
+		if i > 0 {
+			buf.WriteString(",")
+		}
+
+		key, err := json.Marshal(k)
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := json.Marshal(o.values[i])
+		if err != nil {
+			return nil, err
+		}
+
+		buf.Write(key)
+		buf.WriteString(":")
+		buf.Write(value)
+	}
+
+	buf.WriteString("}")
+
+	return buf.Bytes(), nil
+}
+
+func formatValue(v interface{}, null string) string {
+	switch v := v.(type) {
+	case nil:
+		return null
+	case []string:
+		return strings.Join(v, ",")
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	meta "github.com/deepilla/sqlitemeta"
+)
+
+func runStructs(args []string, stdout, stderr io.Writer) int {
+
+	var schema, output string
+	var opts meta.StructOptions
+
+	fs := flag.NewFlagSet("structs", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.StringVar(&schema, "schema", "", "generate structs for the named database")
+	fs.StringVar(&opts.Package, "package", "models", "package name of the generated code")
+	fs.StringVar(&opts.Tag, "tag", "db", "struct tag key for column names")
+	fs.BoolVar(&opts.Pointers, "pointers", false, "use pointers for nullable columns instead of sql.Null* types")
+	fs.StringVar(&output, "o", "", "write to the named file instead of standard output")
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: sqlitemeta structs [flags] <database> [table...]\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	if fs.NArg() < 1 {
+		fs.Usage()
+		return 2
+	}
+
+	db, err := openDB(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(stderr, "sqlitemeta: %s\n", err)
+		return 1
+	}
+	defer db.Close()
+
+	if schema != "" {
+		opts.Schema = meta.DB(schema)
+	}
+	opts.Tables = fs.Args()[1:]
+
+	var buf bytes.Buffer
+
+	if err := meta.GenerateStructs(&buf, db, &opts); err != nil {
+		fmt.Fprintf(stderr, "sqlitemeta: %s\n", err)
+		return 1
+	}
+
+	if output == "" {
+		_, err = stdout.Write(buf.Bytes())
+	} else {
+		err = ioutil.WriteFile(output, buf.Bytes(), 0644)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "sqlitemeta: %s\n", err)
+		return 1
+	}
+
+	return 0
+}
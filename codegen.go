@@ -0,0 +1,335 @@
+package sqlitemeta
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// StructOptions controls the output of GenerateStructs.
+type StructOptions struct {
+	// Package is the package name used in the generated code.
+	// If empty, "models" is used.
+	Package string
+
+	// Schema is the database to generate structs for. If nil,
+	// the main database is used.
+	Schema *Schema
+
+	// Tables restricts code generation to the named tables.
+	// If empty, a struct is generated for every table except
+	// SQLite's internal tables (see ExcludeInternal).
+	Tables []string
+
+	// Pointers causes nullable columns to be represented by
+	// pointer types (e.g. *string) instead of the sql.Null*
+	// types (e.g. sql.NullString).
+	Pointers bool
+
+	// Tag is the struct tag key used to record column names.
+	// If empty, "db" is used.
+	Tag string
+}
+
+// GenerateStructs writes Go source code to w declaring a struct
+// for each table in a database, along with constants for the
+// table and column names and a variable listing the primary key
+// columns.
+//
+// Struct fields are typed according to the column's declared
+// type and affinity. Columns without a NOT NULL constraint are
+// represented by sql.Null* types (or pointers, if opts.Pointers
+// is set), except for INTEGER PRIMARY KEY columns which can
+// never be NULL. Columns declared as DATE, DATETIME or TIMESTAMP
+// are represented by time.Time (or *time.Time if nullable),
+// columns declared as BOOL or BOOLEAN by bool, BLOB columns by
+// []byte and columns with no declared type by interface{}.
+//
+// Identifiers are derived from table and column names. If two
+// names would produce the same identifier, a numeric suffix is
+// added to the later one.
+//
+// GenerateStructs is designed to be run via go generate, e.g.
+// using the sqlitemeta command:
+//
+//     //go:generate sqlitemeta structs -package models -o models.go app.db
+func GenerateStructs(w io.Writer, db Querier, opts *StructOptions) error {
+
+	if opts == nil {
+		opts = &StructOptions{}
+	}
+
+	src, err := generateStructs(db, opts)
+	if err != nil {
+		return fmt.Errorf("could not generate structs: %s", err)
+	}
+
+	_, err = w.Write(src)
+	return err
+}
+
+type goStruct struct {
+	Name       string
+	Table      string
+	TableConst string // The name of the table name constant.
+	PKVar      string // The name of the primary key variable.
+	Fields     []goField
+	Primary    []string // Constant names of the primary key columns.
+}
+
+type goField struct {
+	Name   string
+	Const  string // The name of the column name constant.
+	Type   string
+	Column string
+	IsPK   bool
+}
+
+func generateStructs(db Querier, opts *StructOptions) ([]byte, error) {
+
+	s := opts.Schema
+	if s == nil {
+		s = Main
+	}
+
+	pkg := opts.Package
+	if pkg == "" {
+		pkg = "models"
+	}
+
+	tag := opts.Tag
+	if tag == "" {
+		tag = "db"
+	}
+
+	names, err := s.TableNames(db, ExcludeInternal())
+	if err != nil {
+		return nil, err
+	}
+
+	if len(opts.Tables) > 0 {
+		names, err = selectNames(names, opts.Tables)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	imports := map[string]bool{}
+
+	// All package-level identifiers (types, constants and
+	// variables) share a namespace. Struct names are chosen
+	// first so that they take precedence.
+	globals := map[string]int{}
+
+	structs := make([]goStruct, len(names))
+	for i, name := range names {
+		structs[i] = goStruct{
+			Name:  uniqueName(goName(name), globals),
+			Table: name,
+		}
+	}
+
+	for i := range structs {
+
+		st := &structs[i]
+
+		columns, err := s.Columns(db, st.Table)
+		if err != nil {
+			return nil, err
+		}
+
+		st.TableConst = uniqueName(st.Name+"Table", globals)
+
+		pk := primaryKeyColumns(columns)
+		fieldNames := map[string]int{}
+
+		for _, c := range columns {
+
+			typ, pkg := goType(c, len(pk) == 1, opts.Pointers)
+			if pkg != "" {
+				imports[pkg] = true
+			}
+
+			f := goField{
+				Name:   uniqueName(goName(c.Name), fieldNames),
+				Type:   typ,
+				Column: c.Name,
+				IsPK:   c.PrimaryKey > 0,
+			}
+			f.Const = uniqueName(st.Name+f.Name, globals)
+
+			st.Fields = append(st.Fields, f)
+		}
+
+		if len(pk) > 0 {
+			st.PKVar = uniqueName(st.Name+"PrimaryKey", globals)
+		}
+
+		for _, col := range pk {
+			for _, f := range st.Fields {
+				if f.Column == col {
+					st.Primary = append(st.Primary, f.Const)
+				}
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "// Code generated by sqlitemeta; DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buf, "package %s\n\n", pkg)
+
+	if len(imports) > 0 {
+		buf.WriteString("import (\n")
+		for _, pkg := range []string{"database/sql", "time"} {
+			if imports[pkg] {
+				fmt.Fprintf(&buf, "%q\n", pkg)
+			}
+		}
+		buf.WriteString(")\n\n")
+	}
+
+	for _, st := range structs {
+
+		fmt.Fprintf(&buf, "// %s is the name of the %s table.\n", st.TableConst, st.Table)
+		fmt.Fprintf(&buf, "const %s = %q\n\n", st.TableConst, st.Table)
+
+		if len(st.Fields) > 0 {
+			fmt.Fprintf(&buf, "// Column names for the %s table.\n", st.Table)
+			buf.WriteString("const (\n")
+			for _, f := range st.Fields {
+				fmt.Fprintf(&buf, "%s = %q\n", f.Const, f.Column)
+			}
+			buf.WriteString(")\n\n")
+		}
+
+		if len(st.Primary) > 0 {
+			fmt.Fprintf(&buf, "// %s lists the primary key columns of the %s table.\n", st.PKVar, st.Table)
+			fmt.Fprintf(&buf, "var %s = []string{", st.PKVar)
+			for i, name := range st.Primary {
+				if i > 0 {
+					buf.WriteString(", ")
+				}
+				buf.WriteString(name)
+			}
+			buf.WriteString("}\n\n")
+		}
+
+		fmt.Fprintf(&buf, "// %s represents a row in the %s table.\n", st.Name, st.Table)
+		fmt.Fprintf(&buf, "type %s struct {\n", st.Name)
+		for _, f := range st.Fields {
+			fmt.Fprintf(&buf, "%s %s `%s:%q`", f.Name, f.Type, tag, f.Column)
+			if f.IsPK {
+				buf.WriteString(" // primary key")
+			}
+			buf.WriteString("\n")
+		}
+		buf.WriteString("}\n\n")
+	}
+
+	return format.Source(buf.Bytes())
+}
+
+// goType returns the Go type used to represent a column, and
+// the package (if any) that must be imported to use it.
+func goType(c Column, singlePK bool, pointers bool) (string, string) {
+
+	decl := strings.ToUpper(c.Type)
+	aff := c.Affinity()
+
+	// An INTEGER PRIMARY KEY is an alias for the rowid and
+	// can never be NULL.
+	notNull := c.NotNull || singlePK && c.PrimaryKey == 1 && strings.TrimSpace(decl) == "INTEGER"
+
+	var typ, null string
+
+	switch {
+	case aff == AffinityNumeric && (strings.Contains(decl, "DATE") || strings.Contains(decl, "TIME")):
+		// The sql package has no NullTime type (prior to Go
+		// 1.13) so nullable times always use pointers.
+		if notNull {
+			return "time.Time", "time"
+		}
+		return "*time.Time", "time"
+	case aff == AffinityNumeric && strings.HasPrefix(decl, "BOOL"):
+		typ, null = "bool", "sql.NullBool"
+	case aff == AffinityInteger:
+		typ, null = "int64", "sql.NullInt64"
+	case aff == AffinityText:
+		typ, null = "string", "sql.NullString"
+	case aff == AffinityReal, aff == AffinityNumeric:
+		typ, null = "float64", "sql.NullFloat64"
+	case strings.TrimSpace(decl) == "":
+		// Columns with no declared type can hold any value.
+		return "interface{}", ""
+	default:
+		// A nil []byte represents NULL.
+		return "[]byte", ""
+	}
+
+	switch {
+	case notNull:
+		return typ, ""
+	case pointers:
+		return "*" + typ, ""
+	default:
+		return null, "database/sql"
+	}
+}
+
+// commonInitialisms are capitalised in full when converting
+// names to Go identifiers, as recommended by golint.
+var commonInitialisms = map[string]bool{
+	"API": true, "ASCII": true, "CPU": true, "CSS": true, "DNS": true,
+	"EOF": true, "GUID": true, "HTML": true, "HTTP": true, "HTTPS": true,
+	"ID": true, "IP": true, "JSON": true, "LHS": true, "QPS": true,
+	"RAM": true, "RHS": true, "RPC": true, "SLA": true, "SMTP": true,
+	"SQL": true, "SSH": true, "TCP": true, "TLS": true, "TTL": true,
+	"UDP": true, "UI": true, "UID": true, "UUID": true, "URI": true,
+	"URL": true, "UTF8": true, "VM": true, "XML": true, "XSRF": true,
+	"XSS": true,
+}
+
+// goName converts an SQL name into an exported Go identifier,
+// e.g. "user_id" becomes "UserID".
+func goName(s string) string {
+
+	words := strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	var buf bytes.Buffer
+	for _, w := range words {
+
+		if u := strings.ToUpper(w); commonInitialisms[u] {
+			buf.WriteString(u)
+			continue
+		}
+
+		r := []rune(w)
+		buf.WriteString(strings.ToUpper(string(r[0])))
+		buf.WriteString(string(r[1:]))
+	}
+
+	name := buf.String()
+	if name == "" || !unicode.IsUpper([]rune(name)[0]) {
+		name = "X" + name
+	}
+
+	return name
+}
+
+// uniqueName returns name, with a numeric suffix if necessary
+// to distinguish it from names that have already been used.
+func uniqueName(name string, used map[string]int) string {
+
+	used[name]++
+	if n := used[name]; n > 1 {
+		return uniqueName(fmt.Sprintf("%s%d", name, n), used)
+	}
+
+	return name
+}
@@ -0,0 +1,246 @@
+package sqlitemeta_test
+
+import (
+	"bytes"
+	"database/sql"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"strings"
+	"testing"
+
+	meta "github.com/deepilla/sqlitemeta"
+)
+
+func TestGenerateStructs(t *testing.T) {
+	testWithDB(t, testGenerateStructs)
+}
+
+func testGenerateStructs(t *testing.T, db *sql.DB) {
+
+	exec(t, db, []string{
+		"DROP TABLE IF EXISTS user_accounts",
+		"DROP TABLE IF EXISTS tags",
+		`CREATE TABLE user_accounts (
+            id INTEGER PRIMARY KEY,
+            email VARCHAR(255) NOT NULL,
+            api_key TEXT,
+            score REAL,
+            is_admin BOOLEAN NOT NULL DEFAULT 0,
+            created_at DATETIME NOT NULL,
+            deleted_at DATETIME,
+            avatar BLOB,
+            extra
+        )`,
+		`CREATE TABLE tags (
+            name TEXT NOT NULL,
+            account_id INTEGER NOT NULL,
+            PRIMARY KEY (account_id, name)
+        )`,
+	})
+
+	data := []struct {
+		Title   string
+		Options *meta.StructOptions
+		Source  string
+	}{
+		{
+			Title: "Null Types",
+			Options: &meta.StructOptions{
+				Tables: []string{"user_accounts"},
+			},
+			Source: "// Code generated by sqlitemeta; DO NOT EDIT." + `
+
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// UserAccountsTable is the name of the user_accounts table.
+const UserAccountsTable = "user_accounts"
+
+// Column names for the user_accounts table.
+const (
+	UserAccountsID        = "id"
+	UserAccountsEmail     = "email"
+	UserAccountsAPIKey    = "api_key"
+	UserAccountsScore     = "score"
+	UserAccountsIsAdmin   = "is_admin"
+	UserAccountsCreatedAt = "created_at"
+	UserAccountsDeletedAt = "deleted_at"
+	UserAccountsAvatar    = "avatar"
+	UserAccountsExtra     = "extra"
+)
+
+// UserAccountsPrimaryKey lists the primary key columns of the user_accounts table.
+var UserAccountsPrimaryKey = []string{UserAccountsID}
+
+// UserAccounts represents a row in the user_accounts table.
+type UserAccounts struct {
+	ID        int64           ` + "`db:\"id\"`" + ` // primary key
+	Email     string          ` + "`db:\"email\"`" + `
+	APIKey    sql.NullString  ` + "`db:\"api_key\"`" + `
+	Score     sql.NullFloat64 ` + "`db:\"score\"`" + `
+	IsAdmin   bool            ` + "`db:\"is_admin\"`" + `
+	CreatedAt time.Time       ` + "`db:\"created_at\"`" + `
+	DeletedAt *time.Time      ` + "`db:\"deleted_at\"`" + `
+	Avatar    []byte          ` + "`db:\"avatar\"`" + `
+	Extra     interface{}     ` + "`db:\"extra\"`" + `
+}
+`,
+		},
+		{
+			Title: "Pointers",
+			Options: &meta.StructOptions{
+				Package:  "store",
+				Tables:   []string{"tags", "user_accounts"},
+				Pointers: true,
+				Tag:      "sql",
+			},
+			Source: "// Code generated by sqlitemeta; DO NOT EDIT." + `
+
+package store
+
+import (
+	"time"
+)
+
+// TagsTable is the name of the tags table.
+const TagsTable = "tags"
+
+// Column names for the tags table.
+const (
+	TagsName      = "name"
+	TagsAccountID = "account_id"
+)
+
+// TagsPrimaryKey lists the primary key columns of the tags table.
+var TagsPrimaryKey = []string{TagsAccountID, TagsName}
+
+// Tags represents a row in the tags table.
+type Tags struct {
+	Name      string ` + "`sql:\"name\"`" + `       // primary key
+	AccountID int64  ` + "`sql:\"account_id\"`" + ` // primary key
+}
+
+// UserAccountsTable is the name of the user_accounts table.
+const UserAccountsTable = "user_accounts"
+
+// Column names for the user_accounts table.
+const (
+	UserAccountsID        = "id"
+	UserAccountsEmail     = "email"
+	UserAccountsAPIKey    = "api_key"
+	UserAccountsScore     = "score"
+	UserAccountsIsAdmin   = "is_admin"
+	UserAccountsCreatedAt = "created_at"
+	UserAccountsDeletedAt = "deleted_at"
+	UserAccountsAvatar    = "avatar"
+	UserAccountsExtra     = "extra"
+)
+
+// UserAccountsPrimaryKey lists the primary key columns of the user_accounts table.
+var UserAccountsPrimaryKey = []string{UserAccountsID}
+
+// UserAccounts represents a row in the user_accounts table.
+type UserAccounts struct {
+	ID        int64       ` + "`sql:\"id\"`" + ` // primary key
+	Email     string      ` + "`sql:\"email\"`" + `
+	APIKey    *string     ` + "`sql:\"api_key\"`" + `
+	Score     *float64    ` + "`sql:\"score\"`" + `
+	IsAdmin   bool        ` + "`sql:\"is_admin\"`" + `
+	CreatedAt time.Time   ` + "`sql:\"created_at\"`" + `
+	DeletedAt *time.Time  ` + "`sql:\"deleted_at\"`" + `
+	Avatar    []byte      ` + "`sql:\"avatar\"`" + `
+	Extra     interface{} ` + "`sql:\"extra\"`" + `
+}
+`,
+		},
+	}
+
+	for _, test := range data {
+
+		var buf bytes.Buffer
+		if err := meta.GenerateStructs(&buf, db, test.Options); err != nil {
+			t.Fatalf("%s: GenerateStructs returned error %s", test.Title, err)
+		}
+
+		if got := buf.String(); got != test.Source {
+			t.Errorf("%s: Expected source\n%s\ngot\n%s", test.Title, test.Source, got)
+		}
+	}
+}
+
+func TestGenerateStructsNameCollisions(t *testing.T) {
+	testWithDB(t, testGenerateStructsNameCollisions)
+}
+
+func testGenerateStructsNameCollisions(t *testing.T, db *sql.DB) {
+
+	exec(t, db, []string{
+		// post.table and PostTable, post.tags and the post_tags
+		// struct, post.primary_key and PostPrimaryKey.
+		`CREATE TABLE post (
+            id INTEGER PRIMARY KEY,
+            "table" TEXT,
+            tags TEXT,
+            primary_key TEXT
+        )`,
+		"CREATE TABLE post_tags (post_id INTEGER, tag TEXT NOT NULL)",
+		"CREATE TABLE seq (id INTEGER PRIMARY KEY AUTOINCREMENT)",
+		"INSERT INTO seq DEFAULT VALUES",
+		"CREATE VIRTUAL TABLE docs USING fts4(body)",
+	})
+
+	var buf bytes.Buffer
+
+	err := meta.GenerateStructs(&buf, db, nil)
+	if err != nil {
+		t.Fatalf("GenerateStructs returned error %s", err)
+	}
+
+	src := buf.String()
+
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "models.go", src, 0)
+	if err != nil {
+		t.Fatalf("Could not parse generated code: %s\n%s", err, src)
+	}
+
+	conf := types.Config{
+		Importer: importer.Default(),
+	}
+
+	_, err = conf.Check("models", fset, []*ast.File{f}, nil)
+	if err != nil {
+		t.Fatalf("Generated code does not compile: %s\n%s", err, src)
+	}
+
+	for _, name := range []string{"SqliteSequence", "DocsContent", "DocsSegments"} {
+		if strings.Contains(src, "type "+name+" ") {
+			t.Errorf("Expected no struct for internal table %s", name)
+		}
+	}
+
+	// Ignore the alignment added by gofmt.
+	flat := strings.Join(strings.Fields(src), " ")
+
+	for _, exp := range []string{
+		"type Post struct",
+		"type PostTags struct",
+		"type Docs struct",
+		"PostTable = \"post\"",
+		"PostTable2 = \"table\"",
+		"PostTags2 = \"tags\"",
+		"PostPrimaryKey = \"primary_key\"",
+		"var PostPrimaryKey2 = []string{PostID}",
+	} {
+		if !strings.Contains(flat, exp) {
+			t.Errorf("Expected generated code to contain %q\n%s", exp, src)
+		}
+	}
+}
@@ -0,0 +1,564 @@
+package sqlitemeta
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// A Function is an SQL function provided by a database
+// connection.
+type Function struct {
+	Name    string
+	Builtin bool   // False for application-defined functions.
+	Type    string // "s" (scalar), "a" (aggregate) or "w" (window).
+	Enc     string // The text encoding, e.g. "utf8".
+	NumArgs int    // The number of arguments, or -1 if variable.
+	Flags   int    // The SQLITE_FUNC_* flags, e.g. SQLITE_DETERMINISTIC.
+}
+
+// Collations returns the names of the collating sequences
+// provided by a database connection, sorted by name. This
+// includes the built-in collations BINARY, NOCASE and RTRIM.
+func Collations(db Querier) ([]string, error) {
+
+	var names []string
+
+	err := Main.queryPragma(db, func() error {
+		var err error
+		names, err = queryStrings(db, "SELECT name FROM pragma_collation_list")
+		return err
+	}, func(prefix string) error {
+
+		var seq int
+		var name string
+
+		names = nil
+		return scanRows(db, "PRAGMA collation_list", nil, func() {
+			names = append(names, name)
+		}, &seq, &name)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not get collations: %s", err)
+	}
+
+	// SQLite adds placeholder entries to the collation list for
+	// collations that are named in the schema, so check that
+	// each collation can actually be used.
+	var available []string
+	for _, name := range names {
+		if hasCollation(db, name) {
+			available = append(available, name)
+		}
+	}
+
+	sort.Sort(byLowerString(available))
+
+	return available, nil
+}
+
+// hasCollation reports whether the named collating sequence can
+// be used in a comparison.
+func hasCollation(db Querier, name string) bool {
+	var b bool
+	return db.QueryRowContext(context.Background(), "SELECT 'a' < 'b' COLLATE "+quoteIdent(name)).Scan(&b) == nil
+}
+
+// Functions returns the SQL functions provided by a database
+// connection, sorted by name and number of arguments. Functions
+// that accept different numbers of arguments or text encodings
+// are listed once for each variant.
+//
+// Functions requires SQLite 3.30.0 or later, or an earlier
+// version compiled with SQLITE_INTROSPECTION_PRAGMAS. If it is
+// not available, Functions returns a *CapabilityError.
+func Functions(db Querier) ([]Function, error) {
+
+	q :=
+		`SELECT
+			name,
+			builtin,
+			type,
+			enc,
+			narg,
+			flags
+		FROM
+			pragma_function_list
+		ORDER BY
+			lower(name),
+			narg,
+			enc`
+
+	var fn Function
+	var functions []Function
+
+	err := scanRows(db, q, nil, func() {
+		functions = append(functions, fn)
+	}, &fn.Name, &fn.Builtin, &fn.Type, &fn.Enc, &fn.NumArgs, &fn.Flags)
+	if err != nil {
+		if strings.Contains(err.Error(), "no such table: pragma_function_list") {
+			return nil, &CapabilityError{
+				Feature: "pragma_function_list",
+				Err:     err,
+			}
+		}
+		return nil, fmt.Errorf("could not get functions: %s", err)
+	}
+
+	return functions, nil
+}
+
+// Modules returns the names of the virtual table modules
+// provided by a database connection, sorted by name.
+//
+// Modules requires SQLite 3.30.0 or later, or an earlier version
+// compiled with SQLITE_INTROSPECTION_PRAGMAS. If it is not
+// available, Modules returns a *CapabilityError.
+func Modules(db Querier) ([]string, error) {
+
+	names, err := queryStrings(db, "SELECT name FROM pragma_module_list")
+	if err != nil {
+		if strings.Contains(err.Error(), "no such table: pragma_module_list") {
+			return nil, &CapabilityError{
+				Feature: "pragma_module_list",
+				Err:     err,
+			}
+		}
+		return nil, fmt.Errorf("could not get modules: %s", err)
+	}
+
+	sort.Sort(byLowerString(names))
+
+	return names, nil
+}
+
+type byLowerString []string
+
+func (s byLowerString) Len() int           { return len(s) }
+func (s byLowerString) Less(i, j int) bool { return sqlower(s[i]) < sqlower(s[j]) }
+func (s byLowerString) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+// A Dependency is a collation, function or virtual table module
+// used by a schema object. Table-valued functions, such as
+// json_each, are virtual table modules.
+type Dependency struct {
+	Kind       string // "collation", "function" or "module".
+	Name       string // The name of the collation, function or module.
+	ObjectType string // "table", "index", "view" or "trigger".
+	Object     string // The name of the object that uses it.
+	Table      string // The table that the object belongs to.
+}
+
+// MissingDependencies returns the collations, functions and
+// virtual table modules used by objects in the main database
+// that are not provided by the database connection. Use the
+// Schema.MissingDependencies method to query other databases.
+func MissingDependencies(db Querier) ([]Dependency, error) {
+	return Main.MissingDependencies(db)
+}
+
+// MissingDependencies returns the collations, functions and
+// virtual table modules used by objects in this Schema that
+// are not provided by the database connection, sorted by object
+// type, object name, kind and name. This is useful for checking
+// that a database will work with a different build of SQLite,
+// or with a program that doesn't register the same
+// application-defined functions.
+//
+// Dependencies are found by scanning the SQL that defines each
+// object, so function calls are detected on a best-effort basis.
+//
+// MissingDependencies requires the pragma_function_list and
+// pragma_module_list functions. If they are not available,
+// MissingDependencies returns a *CapabilityError.
+func (s *Schema) MissingDependencies(db Querier) ([]Dependency, error) {
+
+	if s.name == "" {
+		return Main.MissingDependencies(db)
+	}
+
+	objects, err := s.masterObjects(db)
+	if err != nil {
+		return nil, fmt.Errorf("could not get missing dependencies: %s", err)
+	}
+
+	collations, err := Collations(db)
+	if err != nil {
+		return nil, fmt.Errorf("could not get missing dependencies: %s", err)
+	}
+
+	functions, err := Functions(db)
+	if err != nil {
+		if _, ok := err.(*CapabilityError); ok {
+			return nil, err
+		}
+		return nil, fmt.Errorf("could not get missing dependencies: %s", err)
+	}
+
+	modules, err := Modules(db)
+	if err != nil {
+		if _, ok := err.(*CapabilityError); ok {
+			return nil, err
+		}
+		return nil, fmt.Errorf("could not get missing dependencies: %s", err)
+	}
+
+	available := map[string]map[string]bool{
+		"collation": {},
+		"function":  {},
+		"module":    {},
+	}
+
+	for _, name := range collations {
+		available["collation"][sqlower(name)] = true
+	}
+	for _, fn := range functions {
+		available["function"][sqlower(fn.Name)] = true
+	}
+	for _, name := range modules {
+		available["module"][sqlower(name)] = true
+	}
+
+	var missing []Dependency
+	checked := map[string]bool{}
+
+	for _, obj := range objects {
+
+		if !obj.SQL.Valid {
+			continue
+		}
+
+		for _, dep := range sqlDependencies(obj.SQL.String) {
+
+			key := sqlower(dep.Name)
+
+			// Some built-in table-valued functions (e.g.
+			// json_each) aren't listed until they're used.
+			if dep.Kind == "module" && !available["module"][key] && !checked[key] {
+				checked[key] = true
+				if available["module"][key], err = hasEponymousTable(db, dep.Name); err != nil {
+					return nil, fmt.Errorf("could not get missing dependencies: %s", err)
+				}
+			}
+
+			if !available[dep.Kind][key] {
+				dep.ObjectType = obj.Type
+				dep.Object = obj.Name
+				dep.Table = obj.Table
+				missing = append(missing, dep)
+			}
+		}
+	}
+
+	sort.Sort(byDependency(missing))
+
+	return missing, nil
+}
+
+// hasEponymousTable reports whether a virtual table with the
+// given name exists without being created, as is the case for
+// table-valued functions. Querying such a table's columns makes
+// SQLite load the module on demand.
+func hasEponymousTable(db Querier, name string) (bool, error) {
+
+	var count int
+	err := db.QueryRowContext(context.Background(), "SELECT COUNT(*) FROM pragma_table_info(?)", name).Scan(&count)
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+type byDependency []Dependency
+
+func (d byDependency) Len() int      { return len(d) }
+func (d byDependency) Swap(i, j int) { d[i], d[j] = d[j], d[i] }
+func (d byDependency) Less(i, j int) bool {
+	switch {
+	case d[i].ObjectType != d[j].ObjectType:
+		return d[i].ObjectType < d[j].ObjectType
+	case sqlower(d[i].Object) != sqlower(d[j].Object):
+		return sqlower(d[i].Object) < sqlower(d[j].Object)
+	case d[i].Kind != d[j].Kind:
+		return d[i].Kind < d[j].Kind
+	default:
+		return sqlower(d[i].Name) < sqlower(d[j].Name)
+	}
+}
+
+// callPrefixes are the keywords that can precede a function call
+// in an expression, e.g. "SELECT f(x)" or "WHERE f(x)". A name
+// followed by a parenthesis is only treated as a function call if
+// it follows one of these keywords or a punctuation mark. This
+// rules out column types (e.g. "VARCHAR(10)") and table names
+// (e.g. "REFERENCES parent(id)").
+var callPrefixes = map[string]bool{
+	"ALL":       true,
+	"AND":       true,
+	"BETWEEN":   true,
+	"BY":        true,
+	"CASE":      true,
+	"DISTINCT":  true,
+	"ELSE":      true,
+	"ESCAPE":    true,
+	"GLOB":      true,
+	"HAVING":    true,
+	"IS":        true,
+	"LIKE":      true,
+	"MATCH":     true,
+	"NOT":       true,
+	"OR":        true,
+	"REGEXP":    true,
+	"RETURNING": true,
+	"SELECT":    true,
+	"THEN":      true,
+	"WHEN":      true,
+	"WHERE":     true,
+}
+
+// nonCalls are the keywords that can be followed by a
+// parenthesis without being function calls.
+var nonCalls = map[string]bool{
+	"AS":           true,
+	"CAST":         true,
+	"CHECK":        true,
+	"DEFAULT":      true,
+	"EXISTS":       true,
+	"FILTER":       true,
+	"IN":           true,
+	"KEY":          true,
+	"MATERIALIZED": true,
+	"OVER":         true,
+	"RAISE":        true,
+	"REFERENCES":   true,
+	"UNIQUE":       true,
+	"USING":        true,
+	"VALUES":       true,
+}
+
+// sqlDependencies returns the collations, functions and modules
+// referred to in an SQL statement. Only the Kind and Name fields
+// are set.
+func sqlDependencies(sql string) []Dependency {
+
+	var deps []Dependency
+	seen := map[Dependency]bool{}
+
+	add := func(kind, name string) {
+		dep := Dependency{Kind: kind, Name: name}
+		key := Dependency{Kind: kind, Name: sqlower(name)}
+		if !seen[key] {
+			seen[key] = true
+			deps = append(deps, dep)
+		}
+	}
+
+	tokens := tokenize(sql)
+
+	if isVirtualTableSQL(sql) {
+		for i := 0; i+1 < len(tokens); i++ {
+			if tokens[i].isWord("USING") && tokens[i+1].isName() {
+				add("module", tokens[i+1].value())
+				break
+			}
+		}
+	}
+
+	for i, t := range tokens {
+
+		if t.isWord("COLLATE") && i+1 < len(tokens) && tokens[i+1].isName() {
+			add("collation", tokens[i+1].value())
+			continue
+		}
+
+		if t.kind != tokenWord && t.kind != tokenIdent {
+			continue
+		}
+		if i == 0 || i+1 >= len(tokens) || !tokens[i+1].isPunct("(") {
+			continue
+		}
+		if t.kind == tokenWord && nonCalls[strings.ToUpper(t.text)] {
+			continue
+		}
+
+		// Table-valued functions, e.g. "FROM json_each(x)",
+		// are provided by virtual table modules.
+		if isTablePosition(tokens, i) {
+			add("module", t.value())
+			continue
+		}
+
+		prev := tokens[i-1]
+		if prev.kind != tokenPunct && !(prev.kind == tokenWord && callPrefixes[strings.ToUpper(prev.text)]) {
+			continue
+		}
+
+		// Common table expressions look like function calls,
+		// e.g. "WITH a AS (...), b(x) AS (...)".
+		if isCTEName(tokens, i) {
+			continue
+		}
+
+		add("function", t.value())
+	}
+
+	return deps
+}
+
+// clauseStarts are the keywords that end a FROM clause, or begin
+// a clause that can precede it.
+var clauseStarts = map[string]bool{
+	"GROUP":     true,
+	"HAVING":    true,
+	"LIMIT":     true,
+	"ORDER":     true,
+	"RETURNING": true,
+	"SELECT":    true,
+	"SET":       true,
+	"VALUES":    true,
+	"WHERE":     true,
+	"WINDOW":    true,
+}
+
+// isTablePosition reports whether tokens[i] is in a position
+// where a table is expected, i.e. it follows FROM or JOIN, or a
+// comma in a FROM clause.
+func isTablePosition(tokens []token, i int) bool {
+
+	prev := tokens[i-1]
+	if prev.isWord("FROM") || prev.isWord("JOIN") {
+		return true
+	}
+	if !prev.isPunct(",") {
+		return false
+	}
+
+	// Search backwards for the start of the clause, skipping
+	// anything in parentheses.
+	depth := 0
+	for j := i - 2; j >= 0; j-- {
+
+		t := tokens[j]
+
+		switch {
+		case t.isPunct(")"):
+			depth++
+		case t.isPunct("("):
+			if depth == 0 {
+				return false
+			}
+			depth--
+		case depth > 0:
+		case t.isWord("FROM"):
+			return true
+		case t.kind == tokenWord && clauseStarts[strings.ToUpper(t.text)]:
+			return false
+		}
+	}
+
+	return false
+}
+
+// isCTEName reports whether tokens[i] names a common table
+// expression, i.e. it follows WITH or WITH RECURSIVE, or a comma
+// that ends a previous common table expression such as
+//
+//	name(col, ...) AS NOT MATERIALIZED (SELECT ...),
+func isCTEName(tokens []token, i int) bool {
+
+	j := i - 1
+
+	for j >= 0 {
+
+		if tokens[j].isWord("WITH") || tokens[j].isWord("RECURSIVE") {
+			return true
+		}
+		if !tokens[j].isPunct(",") {
+			return false
+		}
+
+		// Work backwards through the previous common table
+		// expression: the parenthesised query...
+		j = openingParen(tokens, j-1)
+		if j < 0 {
+			return false
+		}
+		j--
+
+		// ...the optional MATERIALIZED or NOT MATERIALIZED...
+		if j >= 0 && tokens[j].isWord("MATERIALIZED") {
+			j--
+			if j >= 0 && tokens[j].isWord("NOT") {
+				j--
+			}
+		}
+
+		// ...the AS keyword...
+		if j < 0 || !tokens[j].isWord("AS") {
+			return false
+		}
+		j--
+
+		// ...the optional column list...
+		if j >= 0 && tokens[j].isPunct(")") {
+			j = openingParen(tokens, j) - 1
+		}
+
+		// ...and the name.
+		if j < 0 || !tokens[j].isName() {
+			return false
+		}
+		j--
+	}
+
+	return false
+}
+
+// openingParen returns the index of the parenthesis that opens
+// the one at tokens[i], or -1 if tokens[i] is not a closing
+// parenthesis or there is no opening parenthesis.
+func openingParen(tokens []token, i int) int {
+
+	if i < 0 || !tokens[i].isPunct(")") {
+		return -1
+	}
+
+	depth := 0
+
+	for ; i >= 0; i-- {
+		switch {
+		case tokens[i].isPunct(")"):
+			depth++
+		case tokens[i].isPunct("("):
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+
+	return -1
+}
+
+// closingParen returns the index of the parenthesis that closes
+// the one at tokens[i], or len(tokens) if there isn't one.
+func closingParen(tokens []token, i int) int {
+
+	depth := 0
+
+	for ; i < len(tokens); i++ {
+		switch {
+		case tokens[i].isPunct("("):
+			depth++
+		case tokens[i].isPunct(")"):
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+
+	return len(tokens)
+}
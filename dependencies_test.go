@@ -0,0 +1,162 @@
+package sqlitemeta_test
+
+import (
+	"database/sql"
+	"testing"
+
+	meta "github.com/deepilla/sqlitemeta"
+)
+
+func TestCollationsFunctionsModules(t *testing.T) {
+	testWithDB(t, testCollationsFunctionsModules)
+}
+
+func testCollationsFunctionsModules(t *testing.T, db *sql.DB) {
+
+	collations, err := meta.Collations(db)
+	if err != nil {
+		t.Fatalf("Collations returned error %s", err)
+	}
+
+	if exp := []string{"BINARY", "NOCASE", "RTRIM"}; !equalStringSlices(exp, collations) {
+		t.Errorf("Expected collations %v, got %v", exp, collations)
+	}
+
+	functions, err := meta.Functions(db)
+	if _, ok := err.(*meta.CapabilityError); ok {
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Fatalf("Functions returned error %s", err)
+	}
+
+	found := false
+	for _, fn := range functions {
+		if fn.Name == "substr" && fn.NumArgs == 3 {
+			found = true
+			if !fn.Builtin || fn.Type != "s" {
+				t.Errorf("Expected substr to be a builtin scalar function, got %+v", fn)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("Expected functions to include substr with 3 arguments")
+	}
+
+	modules, err := meta.Modules(db)
+	if err != nil {
+		t.Fatalf("Modules returned error %s", err)
+	}
+
+	for _, name := range modules {
+		if name == "" {
+			t.Errorf("Expected module names, got %q", modules)
+		}
+	}
+}
+
+func TestMissingDependencies(t *testing.T) {
+	testWithDB(t, testMissingDependencies)
+}
+
+func testMissingDependencies(t *testing.T, db *sql.DB) {
+
+	// Objects that use unavailable collations, functions and
+	// modules can't be created in the usual way, so some of
+	// them are added to sqlite_master directly.
+	exec(t, db, []string{
+		"DROP VIEW IF EXISTS sorted",
+		"DROP VIEW IF EXISTS tags",
+		"DROP TRIGGER IF EXISTS audit",
+		"DROP TABLE IF EXISTS items",
+		"CREATE TABLE items (name VARCHAR(20) DEFAULT (upper('x')) CHECK (length(name) < 20) COLLATE NOCASE)",
+		"CREATE INDEX items_name ON items (lower(name) COLLATE RTRIM)",
+		"CREATE VIEW sorted AS SELECT name COLLATE missing_collation, abs(1) FROM items ORDER BY 1",
+		"CREATE VIEW tags AS SELECT i.name, j.value FROM items i, json_each(i.name) AS j JOIN json_tree('{}') ON 1, json_each('[1, 2]')",
+		"CREATE TRIGGER audit AFTER INSERT ON items BEGIN SELECT missing_function(new.name), Missing_Function(1); END",
+		"PRAGMA writable_schema = ON",
+		"INSERT INTO sqlite_master VALUES ('table', 'search', 'search', 0, 'CREATE VIRTUAL TABLE search USING missing_module(name)')",
+		`INSERT INTO sqlite_master VALUES ('view', 'aliased', 'aliased', 0, 'CREATE VIEW aliased AS
+            WITH RECURSIVE c(x) AS (SELECT 1), d AS NOT MATERIALIZED (SELECT 2)
+            SELECT aliased_function(x) AS y, second_function(x) AS z FROM c, d')`,
+		"INSERT INTO sqlite_master VALUES ('view', 'series', 'series', 0, 'CREATE VIEW series AS SELECT value, length(value) FROM items, missing_series(1, 10)')",
+		"PRAGMA writable_schema = OFF",
+	})
+
+	defer exec(t, db, []string{
+		"PRAGMA writable_schema = ON",
+		"DELETE FROM sqlite_master WHERE name IN ('search', 'aliased', 'series')",
+		"PRAGMA writable_schema = OFF",
+	})
+
+	deps, err := meta.MissingDependencies(db)
+	if _, ok := err.(*meta.CapabilityError); ok {
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Fatalf("MissingDependencies returned error %s", err)
+	}
+
+	exp := []meta.Dependency{
+		{
+			Kind:       "module",
+			Name:       "missing_module",
+			ObjectType: "table",
+			Object:     "search",
+			Table:      "search",
+		},
+		{
+			Kind:       "function",
+			Name:       "missing_function",
+			ObjectType: "trigger",
+			Object:     "audit",
+			Table:      "items",
+		},
+		{
+			Kind:       "function",
+			Name:       "aliased_function",
+			ObjectType: "view",
+			Object:     "aliased",
+			Table:      "aliased",
+		},
+		{
+			Kind:       "function",
+			Name:       "second_function",
+			ObjectType: "view",
+			Object:     "aliased",
+			Table:      "aliased",
+		},
+		{
+			Kind:       "module",
+			Name:       "missing_series",
+			ObjectType: "view",
+			Object:     "series",
+			Table:      "series",
+		},
+		{
+			Kind:       "collation",
+			Name:       "missing_collation",
+			ObjectType: "view",
+			Object:     "sorted",
+			Table:      "sorted",
+		},
+	}
+
+	compareStructSlices(t, "", "dependency", "dependencies", exp, deps)
+
+	collations, err := meta.Collations(db)
+	if err != nil {
+		t.Fatalf("Collations returned error %s", err)
+	}
+
+	for _, name := range collations {
+		if name == "missing_collation" {
+			t.Errorf("Expected collations to exclude missing_collation, got %v", collations)
+		}
+	}
+
+	_, err = meta.DB("xxxxx").MissingDependencies(db)
+	if exp := "could not get missing dependencies: unknown database 'xxxxx'"; err == nil || err.Error() != exp {
+		t.Errorf("Expected error %q, got %v", exp, err)
+	}
+}
@@ -0,0 +1,290 @@
+package sqlitemeta
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DiagramOptions controls which tables are included in an
+// entity-relationship diagram.
+type DiagramOptions struct {
+	// Schema is the database to diagram. If nil, the main
+	// database is used.
+	Schema *Schema
+
+	// Tables restricts the diagram to the named tables. If
+	// empty, all of the tables in the Schema are included.
+	// Foreign keys that refer to tables outside of this list
+	// are omitted.
+	Tables []string
+}
+
+// A diagram is the table and relationship information needed
+// to draw an entity-relationship diagram.
+type diagram struct {
+	name   string
+	tables []*diagramTable
+	edges  []*diagramEdge
+}
+
+type diagramTable struct {
+	name        string
+	columns     []Column
+	foreignKeys []ForeignKey
+	indexes     []Index
+}
+
+// A diagramEdge represents a foreign key relationship between
+// two tables in a diagram.
+type diagramEdge struct {
+	child      *diagramTable
+	parent     *diagramTable
+	foreignKey ForeignKey
+	childKey   []string
+	parentKey  []string // Empty if the parent key could not be determined.
+
+	// unique is true if the child key is covered by a primary
+	// key or unique index, i.e. each parent row has at most one
+	// child row.
+	unique bool
+
+	// optional is true if any of the child key columns are
+	// nullable, i.e. a child row need not have a parent.
+	optional bool
+}
+
+func loadDiagram(db Querier, opts *DiagramOptions) (*diagram, error) {
+
+	if opts == nil {
+		opts = &DiagramOptions{}
+	}
+
+	s := opts.Schema
+	if s == nil {
+		s = Main
+	}
+
+	names, err := s.TableNames(db)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(opts.Tables) > 0 {
+		names, err = selectNames(names, opts.Tables)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	d := &diagram{
+		name: s.name,
+	}
+
+	byName := map[string]*diagramTable{}
+
+	for _, name := range names {
+
+		t := &diagramTable{
+			name: name,
+		}
+
+		if t.columns, err = s.Columns(db, name); err != nil {
+			return nil, err
+		}
+		if t.foreignKeys, err = s.ForeignKeys(db, name); err != nil {
+			return nil, err
+		}
+		if t.indexes, err = s.Indexes(db, name); err != nil {
+			return nil, err
+		}
+
+		d.tables = append(d.tables, t)
+		byName[sqlower(name)] = t
+	}
+
+	for _, t := range d.tables {
+		for _, fk := range t.foreignKeys {
+
+			parent, ok := byName[sqlower(fk.ParentTable)]
+			if !ok {
+				continue
+			}
+
+			d.edges = append(d.edges, &diagramEdge{
+				child:      t,
+				parent:     parent,
+				foreignKey: fk,
+				childKey:   fk.ChildKey,
+				parentKey:  resolveParentKey(fk, parent.columns),
+				unique:     isUniqueKey(fk.ChildKey, t.columns, t.indexes),
+				optional:   isNullableKey(fk.ChildKey, t.columns),
+			})
+		}
+	}
+
+	return d, nil
+}
+
+// selectNames returns the names in available that match the
+// names in wanted (using SQLite's case-insensitive matching),
+// preserving the order of available.
+func selectNames(available, wanted []string) ([]string, error) {
+
+	found := map[string]bool{}
+	for _, name := range available {
+		found[sqlower(name)] = false
+	}
+
+	for _, name := range wanted {
+		if _, ok := found[sqlower(name)]; !ok {
+			return nil, fmt.Errorf("unknown table %s", name)
+		}
+		found[sqlower(name)] = true
+	}
+
+	var names []string
+	for _, name := range available {
+		if found[sqlower(name)] {
+			names = append(names, name)
+		}
+	}
+
+	return names, nil
+}
+
+// primaryKeyColumns returns the names of the primary key
+// columns in the given slice, in primary key order.
+func primaryKeyColumns(columns []Column) []string {
+
+	var pk []Column
+	for _, c := range columns {
+		if c.PrimaryKey > 0 {
+			pk = append(pk, c)
+		}
+	}
+
+	sort.Sort(byPrimaryKey(pk))
+
+	var names []string
+	for _, c := range pk {
+		names = append(names, c.Name)
+	}
+
+	return names
+}
+
+type byPrimaryKey []Column
+
+func (c byPrimaryKey) Len() int           { return len(c) }
+func (c byPrimaryKey) Less(i, j int) bool { return c[i].PrimaryKey < c[j].PrimaryKey }
+func (c byPrimaryKey) Swap(i, j int)      { c[i], c[j] = c[j], c[i] }
+
+// resolveParentKey returns the parent key columns of a foreign
+// key. If the REFERENCES clause omits the parent columns, the
+// primary key of the parent table is used.
+func resolveParentKey(fk ForeignKey, parentColumns []Column) []string {
+
+	var names []string
+	for _, name := range fk.ParentKey {
+		if !name.Valid {
+			names = nil
+			break
+		}
+		names = append(names, name.String)
+	}
+
+	if names != nil {
+		return names
+	}
+
+	names = primaryKeyColumns(parentColumns)
+	if len(names) != len(fk.ChildKey) {
+		return nil
+	}
+
+	return names
+}
+
+// isUniqueKey reports whether the given columns are guaranteed
+// to be unique, i.e. whether they form the primary key or the
+// key of a (non-partial) unique index.
+func isUniqueKey(key []string, columns []Column, indexes []Index) bool {
+
+	if equalNameSets(key, primaryKeyColumns(columns)) {
+		return true
+	}
+
+	for _, idx := range indexes {
+
+		if !idx.IsUnique || idx.IsPartial {
+			continue
+		}
+
+		var names []string
+		for _, name := range idx.ColumnNames {
+			if !name.Valid {
+				names = nil
+				break
+			}
+			names = append(names, name.String)
+		}
+
+		if names != nil && equalNameSets(key, names) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isNullableKey reports whether any of the given columns can
+// hold NULL values.
+func isNullableKey(key []string, columns []Column) bool {
+
+	pk := primaryKeyColumns(columns)
+
+	for _, name := range key {
+		for _, c := range columns {
+
+			if sqlower(c.Name) != sqlower(name) || c.NotNull {
+				continue
+			}
+
+			// An INTEGER PRIMARY KEY is an alias for the rowid
+			// and can never be NULL.
+			if len(pk) == 1 && c.PrimaryKey == 1 && strings.EqualFold(c.Type, "INTEGER") {
+				continue
+			}
+
+			return true
+		}
+	}
+
+	return false
+}
+
+// equalNameSets reports whether two slices contain the same
+// column names, ignoring order and case.
+func equalNameSets(a, b []string) bool {
+
+	if len(a) == 0 || len(a) != len(b) {
+		return false
+	}
+
+	counts := map[string]int{}
+	for _, name := range a {
+		counts[sqlower(name)]++
+	}
+	for _, name := range b {
+		counts[sqlower(name)]--
+	}
+
+	for _, n := range counts {
+		if n != 0 {
+			return false
+		}
+	}
+
+	return true
+}
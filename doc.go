@@ -48,5 +48,19 @@ the temp database takes precedence, followed by the main database,
 followed by any other attached databases (in order of attachment).
 
 See https://sqlite.org/lang_naming.html for details.
+
+Attached databases belong to the connection that attached them, and
+a *sql.DB is a pool of connections. To work with attached databases,
+use Attach to attach them to a *sql.Conn, and pass the same *sql.Conn
+to functions that accept a Querier.
+
+SQLite Versions
+
+This package queries metadata using PRAGMA functions (e.g.
+pragma_table_info) which were introduced in SQLite 3.16.0. With older
+versions of SQLite, it falls back to running the equivalent PRAGMA
+statements. Some features, such as storage usage, depend on options
+that SQLite was compiled with. Use the Capabilities function to check
+which features are available.
 */
 package sqlitemeta
@@ -0,0 +1,417 @@
+package sqlitemeta
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	texttemplate "text/template"
+)
+
+// SchemaDoc describes a database for documentation purposes.
+type SchemaDoc struct {
+	Name   string
+	Tables []TableDoc
+}
+
+// TableDoc describes a table for documentation purposes.
+type TableDoc struct {
+	Schema      string
+	Name        string
+	SQL         string
+	Columns     []Column
+	Indexes     []IndexDoc
+	ForeignKeys []ForeignKeyDoc // Foreign keys defined on this table.
+	References  []ForeignKeyDoc // Foreign keys in other tables that refer to this table.
+	Triggers    []TriggerDoc
+}
+
+// IndexDoc describes an index for documentation purposes.
+type IndexDoc struct {
+	Index
+	Columns []IndexColumn
+	SQL     string // Empty for indexes created by SQLite (e.g. for UNIQUE constraints).
+}
+
+// ForeignKeyDoc describes a foreign key for documentation
+// purposes. Unlike a ForeignKey, a ForeignKeyDoc includes the
+// name of the child table and always specifies the parent key
+// columns (where they can be determined). If the parent table
+// exists, ParentTable is its name as defined in the schema.
+type ForeignKeyDoc struct {
+	Table        string
+	ChildKey     []string
+	ParentTable  string
+	ParentKey    []string
+	ParentExists bool // False if the parent table is not in the schema.
+	OnUpdate     ForeignKeyAction
+	OnDelete     ForeignKeyAction
+}
+
+// TriggerDoc describes a trigger for documentation purposes.
+type TriggerDoc struct {
+	Name string
+	SQL  string
+}
+
+// SchemaDocs returns documentation for every table in every
+// database attached to the given database connection. The
+// results are suitable for passing to a DocGenerator.
+func SchemaDocs(db Querier) ([]SchemaDoc, error) {
+
+	names, err := SchemaNames(db)
+	if err != nil {
+		return nil, fmt.Errorf("could not get schema docs: %s", err)
+	}
+
+	var docs []SchemaDoc
+
+	for _, name := range names {
+
+		doc, err := DB(name).doc(db)
+		if err != nil {
+			return nil, fmt.Errorf("could not get schema docs for %s: %s", name, err)
+		}
+
+		docs = append(docs, doc)
+	}
+
+	return docs, nil
+}
+
+func (s *Schema) doc(db Querier) (SchemaDoc, error) {
+
+	objects, err := s.masterObjects(db)
+	if err != nil {
+		return SchemaDoc{Name: s.name}, err
+	}
+
+	tables, indexSQL := docTables(objects)
+	foreignKeys := make([][]ForeignKey, len(tables))
+
+	for i := range tables {
+		if foreignKeys[i], err = s.tableDoc(db, &tables[i], indexSQL); err != nil {
+			return SchemaDoc{Name: s.name}, err
+		}
+	}
+
+	return s.buildDoc(tables, foreignKeys, objects), nil
+}
+
+// docTables returns a TableDoc for each table in objects, with
+// only the Name and SQL fields set. It also returns the SQL for
+// each index, keyed by lowercase index name.
+func docTables(objects []masterObject) ([]TableDoc, map[string]string) {
+
+	var tables []TableDoc
+	indexSQL := map[string]string{}
+
+	for _, obj := range objects {
+		switch obj.Type {
+		case "table":
+			tables = append(tables, TableDoc{
+				Name: obj.Name,
+				SQL:  obj.SQL.String,
+			})
+		case "index":
+			indexSQL[sqlower(obj.Name)] = obj.SQL.String
+		}
+	}
+
+	return tables, indexSQL
+}
+
+// tableDoc fills in the columns and indexes of a TableDoc and
+// returns the table's foreign keys. It only runs queries that
+// concern the given table, so it can be called concurrently
+// for different tables.
+func (s *Schema) tableDoc(db Querier, t *TableDoc, indexSQL map[string]string) ([]ForeignKey, error) {
+
+	var err error
+
+	t.Schema = s.name
+
+	if t.Columns, err = s.Columns(db, t.Name); err != nil {
+		return nil, err
+	}
+
+	indexes, err := s.Indexes(db, t.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, idx := range indexes {
+
+		columns, err := s.indexColumns(db, idx.Name, false)
+		if err != nil {
+			return nil, err
+		}
+
+		t.Indexes = append(t.Indexes, IndexDoc{
+			Index:   idx,
+			Columns: columns,
+			SQL:     indexSQL[sqlower(idx.Name)],
+		})
+	}
+
+	return s.ForeignKeys(db, t.Name)
+}
+
+// buildDoc combines the results of tableDoc into a SchemaDoc,
+// resolving foreign key references between tables and adding
+// triggers.
+func (s *Schema) buildDoc(tables []TableDoc, foreignKeys [][]ForeignKey, objects []masterObject) SchemaDoc {
+
+	doc := SchemaDoc{
+		Name:   s.name,
+		Tables: tables,
+	}
+
+	tablesByName := map[string]*TableDoc{}
+	for i := range doc.Tables {
+		tablesByName[sqlower(doc.Tables[i].Name)] = &doc.Tables[i]
+	}
+
+	for i := range doc.Tables {
+
+		t := &doc.Tables[i]
+
+		for _, fk := range foreignKeys[i] {
+
+			fkdoc := ForeignKeyDoc{
+				Table:       t.Name,
+				ChildKey:    fk.ChildKey,
+				ParentTable: fk.ParentTable,
+				OnUpdate:    fk.OnUpdate,
+				OnDelete:    fk.OnDelete,
+			}
+
+			// Use the parent table's name as it appears in the
+			// schema rather than as written in the REFERENCES
+			// clause so that links to its page work.
+			parent := tablesByName[sqlower(fk.ParentTable)]
+			if parent != nil {
+				fkdoc.ParentTable = parent.Name
+				fkdoc.ParentExists = true
+				fkdoc.ParentKey = resolveParentKey(fk, parent.Columns)
+				parent.References = append(parent.References, fkdoc)
+			} else {
+				// Only an explicit parent key is known.
+				fkdoc.ParentKey = resolveParentKey(fk, nil)
+			}
+
+			t.ForeignKeys = append(t.ForeignKeys, fkdoc)
+		}
+	}
+
+	for _, obj := range objects {
+		if obj.Type == "trigger" {
+			if t := tablesByName[sqlower(obj.Table)]; t != nil {
+				t.Triggers = append(t.Triggers, TriggerDoc{
+					Name: obj.Name,
+					SQL:  obj.SQL.String,
+				})
+			}
+		}
+	}
+
+	return doc
+}
+
+// A DocGenerator writes schema documentation as a set of
+// Markdown or HTML pages: an index page listing every table
+// and one page per table.
+//
+// Pages are generated from templates. A template must define
+// two named templates: "index", which is executed with a value
+// of type struct{ Schemas []SchemaDoc }, and "table", which is
+// executed with a TableDoc. To customise the output, start
+// with the default templates and redefine one or both, e.g.
+//
+//     tmpl := sqlitemeta.NewMarkdownTemplate()
+//     tmpl = template.Must(tmpl.Parse(`{{define "index"}}...{{end}}`))
+//
+//     g := &sqlitemeta.DocGenerator{Markdown: tmpl}
+//
+// The default templates provide the following functions:
+//
+//     page SCHEMA TABLE  the filename of a table's page
+//     indexPage          the filename of the index page
+//     str VALUE          converts a []byte or sql.NullString to a string
+//     join LIST SEP      strings.Join
+//     md STRING          escapes Markdown special characters (Markdown only)
+type DocGenerator struct {
+	// Markdown is the template used by WriteMarkdown. If nil,
+	// the template returned by NewMarkdownTemplate is used.
+	Markdown *texttemplate.Template
+
+	// HTML is the template used by WriteHTML. If nil, the
+	// template returned by NewHTMLTemplate is used.
+	HTML *htmltemplate.Template
+}
+
+// WriteMarkdown writes Markdown documentation for the given
+// schemas to the directory dir, creating it if necessary.
+func (g *DocGenerator) WriteMarkdown(dir string, schemas []SchemaDoc) error {
+
+	t := g.Markdown
+	if t == nil {
+		t = NewMarkdownTemplate()
+	}
+
+	if err := writeDocs(dir, markdownExt, t, schemas); err != nil {
+		return fmt.Errorf("could not write Markdown docs: %s", err)
+	}
+
+	return nil
+}
+
+// WriteHTML writes HTML documentation for the given schemas to
+// the directory dir, creating it if necessary. The default
+// template produces self-contained pages with no external
+// stylesheets or scripts.
+func (g *DocGenerator) WriteHTML(dir string, schemas []SchemaDoc) error {
+
+	t := g.HTML
+	if t == nil {
+		t = NewHTMLTemplate()
+	}
+
+	if err := writeDocs(dir, htmlExt, t, schemas); err != nil {
+		return fmt.Errorf("could not write HTML docs: %s", err)
+	}
+
+	return nil
+}
+
+const (
+	markdownExt = ".md"
+	htmlExt     = ".html"
+)
+
+// NewMarkdownTemplate returns a new copy of the default
+// template used to generate Markdown documentation.
+func NewMarkdownTemplate() *texttemplate.Template {
+	funcs := docFuncs(markdownExt)
+	funcs["md"] = markdownEscape
+	return texttemplate.Must(texttemplate.New("docs").Funcs(funcs).Parse(markdownTemplate))
+}
+
+// NewHTMLTemplate returns a new copy of the default template
+// used to generate HTML documentation.
+func NewHTMLTemplate() *htmltemplate.Template {
+	return htmltemplate.Must(htmltemplate.New("docs").Funcs(docFuncs(htmlExt)).Parse(htmlTemplate))
+}
+
+// docTemplate is implemented by both text/template and
+// html/template Templates.
+type docTemplate interface {
+	ExecuteTemplate(w io.Writer, name string, data interface{}) error
+}
+
+func writeDocs(dir, ext string, t docTemplate, schemas []SchemaDoc) error {
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	index := struct {
+		Schemas []SchemaDoc
+	}{
+		schemas,
+	}
+
+	if err := writeDocPage(filepath.Join(dir, "index"+ext), t, "index", index); err != nil {
+		return err
+	}
+
+	for _, s := range schemas {
+		for _, tbl := range s.Tables {
+			if err := writeDocPage(filepath.Join(dir, docPage(s.Name, tbl.Name, ext)), t, "table", tbl); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func writeDocPage(filename string, t docTemplate, name string, data interface{}) error {
+
+	var buf bytes.Buffer
+
+	if err := t.ExecuteTemplate(&buf, name, data); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(filename, buf.Bytes(), 0644)
+}
+
+func docFuncs(ext string) map[string]interface{} {
+	return map[string]interface{}{
+		"page": func(schema, table string) string {
+			return docPage(schema, table, ext)
+		},
+		"indexPage": func() string {
+			return "index" + ext
+		},
+		"str":  docString,
+		"join": strings.Join,
+	}
+}
+
+// docPage returns the filename of the documentation page for
+// the given table. Characters other than ASCII letters, digits
+// and underscores are hex-encoded (e.g. a space becomes "-20")
+// so that filenames are portable and unique.
+func docPage(schema, table, ext string) string {
+
+	escape := func(s string) string {
+		var buf bytes.Buffer
+		for i := 0; i < len(s); i++ {
+			switch c := s[i]; {
+			case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '_':
+				buf.WriteByte(c)
+			default:
+				fmt.Fprintf(&buf, "-%02x", c)
+			}
+		}
+		return buf.String()
+	}
+
+	return escape(schema) + "." + escape(table) + ext
+}
+
+func docString(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case sql.NullString:
+		return v.String
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+var markdownReplacer = strings.NewReplacer(
+	`\`, `\\`,
+	"`", "\\`",
+	`*`, `\*`,
+	`_`, `\_`,
+	`|`, `\|`,
+	`[`, `\[`,
+	`]`, `\]`,
+	`<`, `&lt;`,
+	`>`, `&gt;`,
+)
+
+func markdownEscape(v interface{}) string {
+	return markdownReplacer.Replace(docString(v))
+}
@@ -0,0 +1,313 @@
+package sqlitemeta_test
+
+import (
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"text/template"
+
+	meta "github.com/deepilla/sqlitemeta"
+)
+
+func TestSchemaDocs(t *testing.T) {
+	testWithDB(t, testSchemaDocs)
+}
+
+func testSchemaDocs(t *testing.T, db *sql.DB) {
+
+	exec(t, db, diagramSQL)
+	exec(t, db, []string{
+		"CREATE INDEX idx_posts_title ON posts(title COLLATE NOCASE DESC, user_id)",
+		`CREATE TRIGGER trg_users_delete AFTER DELETE ON users
+            BEGIN
+                DELETE FROM posts WHERE user_id = OLD.id;
+            END`,
+	})
+
+	docs, err := meta.SchemaDocs(db)
+	if err != nil {
+		t.Fatalf("SchemaDocs returned error %s", err)
+	}
+
+	if len(docs) != 1 || docs[0].Name != "main" {
+		t.Fatalf("Expected docs for schema main, got %v", docs)
+	}
+
+	var names []string
+	tables := map[string]meta.TableDoc{}
+	for _, tbl := range docs[0].Tables {
+		names = append(names, tbl.Name)
+		tables[tbl.Name] = tbl
+	}
+
+	if exp := []string{"posts", "profiles", "users"}; !equalStringSlices(exp, names) {
+		t.Fatalf("Expected tables %v, got %v", exp, names)
+	}
+
+	posts := tables["posts"]
+
+	if len(posts.Indexes) != 1 {
+		t.Fatalf("Expected 1 index on posts, got %d", len(posts.Indexes))
+	}
+
+	idx := posts.Indexes[0]
+	if idx.Name != "idx_posts_title" || !strings.HasPrefix(idx.SQL, "CREATE INDEX idx_posts_title") {
+		t.Errorf("Unexpected index %s with SQL %q", idx.Name, idx.SQL)
+	}
+
+	expColumns := []meta.IndexColumn{
+		{
+			Name:       nullString("title"),
+			Rank:       0,
+			TableRank:  2,
+			Descending: true,
+			Collation:  "NOCASE",
+			IsKey:      true,
+		},
+		{
+			Name:      nullString("user_id"),
+			Rank:      1,
+			TableRank: 1,
+			Collation: "BINARY",
+			IsKey:     true,
+		},
+	}
+
+	compareStructSlices(t, "posts index", "column", "column(s)", expColumns, idx.Columns)
+
+	expForeignKeys := []meta.ForeignKeyDoc{
+		{
+			Table:        "posts",
+			ChildKey:     []string{"user_id"},
+			ParentTable:  "users",
+			ParentKey:    []string{"id"},
+			ParentExists: true,
+			OnDelete:     meta.ForeignKeyActionSetNull,
+		},
+	}
+
+	compareStructSlices(t, "posts", "foreign key", "foreign key(s)", expForeignKeys, posts.ForeignKeys)
+
+	expReferences := []meta.ForeignKeyDoc{
+		{
+			Table:        "posts",
+			ChildKey:     []string{"user_id"},
+			ParentTable:  "users",
+			ParentKey:    []string{"id"},
+			ParentExists: true,
+			OnDelete:     meta.ForeignKeyActionSetNull,
+		},
+		{
+			Table:        "profiles",
+			ChildKey:     []string{"user_id"},
+			ParentTable:  "users",
+			ParentKey:    []string{"id"},
+			ParentExists: true,
+			OnDelete:     meta.ForeignKeyActionCascade,
+		},
+	}
+
+	users := tables["users"]
+	compareStructSlices(t, "users", "reference", "reference(s)", expReferences, users.References)
+
+	if len(users.Triggers) != 1 || users.Triggers[0].Name != "trg_users_delete" {
+		t.Errorf("Expected users to have trigger trg_users_delete, got %v", users.Triggers)
+	}
+
+	dir, err := ioutil.TempDir("", "sqlitemeta-docs")
+	if err != nil {
+		t.Fatalf("Could not create temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	g := &meta.DocGenerator{}
+
+	if err := g.WriteMarkdown(dir, docs); err != nil {
+		t.Fatalf("WriteMarkdown returned error %s", err)
+	}
+	if err := g.WriteHTML(dir, docs); err != nil {
+		t.Fatalf("WriteHTML returned error %s", err)
+	}
+
+	pages := map[string][]string{
+		"index.md": {
+			"- [posts](main.posts.md)",
+			"- [users](main.users.md)",
+		},
+		"main.posts.md": {
+			"# main.posts",
+			"| 1 | user\\_id | INTEGER | No |  |  |",
+			"| title | DESC | NOCASE |",
+			"- (user\\_id) references [users](main.users.md) (id) ON UPDATE NO ACTION ON DELETE SET NULL",
+		},
+		"main.users.md": {
+			"- [profiles](main.profiles.md) (user\\_id) references (id) ON UPDATE NO ACTION ON DELETE CASCADE",
+			"### trg\\_users\\_delete",
+		},
+		"index.html": {
+			`<li><a href="main.posts.html">posts</a></li>`,
+		},
+		"main.posts.html": {
+			"<h1>main.posts</h1>",
+			"<tr><td>title</td><td>DESC</td><td>NOCASE</td></tr>",
+			"<pre>CREATE TABLE posts (",
+		},
+	}
+
+	for name, snippets := range pages {
+
+		data, err := ioutil.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Errorf("Could not read page %s: %s", name, err)
+			continue
+		}
+
+		for _, s := range snippets {
+			if !strings.Contains(string(data), s) {
+				t.Errorf("Expected page %s to contain %q, got\n%s", name, s, data)
+			}
+		}
+	}
+
+	tmpl := meta.NewMarkdownTemplate()
+	tmpl = template.Must(tmpl.Parse(`{{define "table"}}{{.Name}} has {{len .Columns}} columns{{end}}`))
+
+	g = &meta.DocGenerator{
+		Markdown: tmpl,
+	}
+
+	if err := g.WriteMarkdown(dir, docs); err != nil {
+		t.Fatalf("WriteMarkdown returned error %s", err)
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, "main.users.md"))
+	if err != nil {
+		t.Fatalf("Could not read page: %s", err)
+	}
+
+	if exp := "users has 2 columns"; string(data) != exp {
+		t.Errorf("Expected custom page %q, got %q", exp, data)
+	}
+}
+
+func TestSchemaDocsParentName(t *testing.T) {
+	testWithDB(t, testSchemaDocsParentName)
+}
+
+func testSchemaDocsParentName(t *testing.T, db *sql.DB) {
+
+	// The REFERENCES clause uses a different case from the
+	// parent table's definition.
+	exec(t, db, []string{
+		"CREATE TABLE Authors (id INTEGER PRIMARY KEY, name TEXT)",
+		"CREATE TABLE books (id INTEGER PRIMARY KEY, author_id INTEGER REFERENCES AUTHORS(id))",
+	})
+
+	docs, err := meta.SchemaDocs(db)
+	if err != nil {
+		t.Fatalf("SchemaDocs returned error %s", err)
+	}
+
+	if len(docs) != 1 || len(docs[0].Tables) != 2 {
+		t.Fatalf("Expected docs for 2 tables, got %v", docs)
+	}
+
+	authors, books := docs[0].Tables[0], docs[0].Tables[1]
+
+	if len(books.ForeignKeys) != 1 || books.ForeignKeys[0].ParentTable != "Authors" {
+		t.Errorf("Expected books to reference Authors, got %v", books.ForeignKeys)
+	}
+	if len(authors.References) != 1 || authors.References[0].ParentTable != "Authors" {
+		t.Errorf("Expected Authors to be referenced by books, got %v", authors.References)
+	}
+
+	dir, err := ioutil.TempDir("", "sqlitemeta-docs")
+	if err != nil {
+		t.Fatalf("Could not create temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	g := &meta.DocGenerator{}
+
+	if err := g.WriteMarkdown(dir, docs); err != nil {
+		t.Fatalf("WriteMarkdown returned error %s", err)
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, "main.books.md"))
+	if err != nil {
+		t.Fatalf("Could not read page: %s", err)
+	}
+
+	if exp := "references [Authors](main.Authors.md) (id)"; !strings.Contains(string(data), exp) {
+		t.Errorf("Expected page to contain %q, got\n%s", exp, data)
+	}
+}
+
+func TestSchemaDocsDanglingForeignKey(t *testing.T) {
+	testWithDB(t, testSchemaDocsDanglingForeignKey)
+}
+
+func testSchemaDocsDanglingForeignKey(t *testing.T, db *sql.DB) {
+
+	exec(t, db, []string{
+		"CREATE TABLE orphans (id INTEGER PRIMARY KEY, parent_id INTEGER REFERENCES missing(id))",
+	})
+
+	docs, err := meta.SchemaDocs(db)
+	if err != nil {
+		t.Fatalf("SchemaDocs returned error %s", err)
+	}
+
+	if len(docs) != 1 || len(docs[0].Tables) != 1 {
+		t.Fatalf("Expected docs for 1 table, got %v", docs)
+	}
+
+	expForeignKeys := []meta.ForeignKeyDoc{
+		{
+			Table:       "orphans",
+			ChildKey:    []string{"parent_id"},
+			ParentTable: "missing",
+			ParentKey:   []string{"id"},
+		},
+	}
+
+	compareStructSlices(t, "orphans", "foreign key", "foreign key(s)", expForeignKeys, docs[0].Tables[0].ForeignKeys)
+
+	dir, err := ioutil.TempDir("", "sqlitemeta-docs")
+	if err != nil {
+		t.Fatalf("Could not create temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	g := &meta.DocGenerator{}
+
+	if err := g.WriteMarkdown(dir, docs); err != nil {
+		t.Fatalf("WriteMarkdown returned error %s", err)
+	}
+	if err := g.WriteHTML(dir, docs); err != nil {
+		t.Fatalf("WriteHTML returned error %s", err)
+	}
+
+	// There is no page for the missing table so it should not
+	// be linked.
+	pages := map[string]string{
+		"main.orphans.md":   "references missing (id)",
+		"main.orphans.html": "references missing (id)",
+	}
+
+	for name, exp := range pages {
+
+		data, err := ioutil.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Errorf("Could not read page %s: %s", name, err)
+			continue
+		}
+
+		if !strings.Contains(string(data), exp) || strings.Contains(string(data), "main.missing.") {
+			t.Errorf("Expected page %s to contain %q without a link, got\n%s", name, exp, data)
+		}
+	}
+}
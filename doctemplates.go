@@ -0,0 +1,182 @@
+package sqlitemeta
+
+// markdownTemplate is the default template for Markdown
+// documentation. See DocGenerator for details.
+const markdownTemplate = `
+{{- define "index" -}}
+# Database Schema
+{{range .Schemas}}
+## {{md .Name}}
+{{range .Tables}}
+- [{{md .Name}}]({{page .Schema .Name}})
+{{- else}}
+No tables.
+{{- end}}
+{{end -}}
+{{end}}
+
+{{- define "table" -}}
+# {{md .Schema}}.{{md .Name}}
+
+[Back to index]({{indexPage}})
+
+## Columns
+
+| # | Name | Type | Not Null | Default | Primary Key |
+| --- | --- | --- | --- | --- | --- |
+{{range .Columns -}}
+| {{.ID}} | {{md .Name}} | {{md .Type}} | {{if .NotNull}}Yes{{else}}No{{end}} | {{md (str .Default)}} | {{if .PrimaryKey}}{{.PrimaryKey}}{{end}} |
+{{end}}
+## Indexes
+{{range .Indexes}}
+### {{md .Name}}
+
+{{if .IsUnique}}Unique{{else}}Non-unique{{end}} {{.Type}} index{{if .IsPartial}} (partial){{end}}.
+
+| Column | Order | Collation |
+| --- | --- | --- |
+{{range .Columns -}}
+| {{if .Name.Valid}}{{md .Name.String}}{{else}}*expression*{{end}} | {{if .Descending}}DESC{{else}}ASC{{end}} | {{md .Collation}} |
+{{end}}
+{{- with .SQL}}
+` + "```sql" + `
+{{.}}
+` + "```" + `
+{{end}}
+{{- else}}
+None.
+{{end}}
+## Foreign Keys
+{{range .ForeignKeys}}
+- ({{md (join .ChildKey ", ")}}) references {{if .ParentExists}}[{{md .ParentTable}}]({{page $.Schema .ParentTable}}){{else}}{{md .ParentTable}}{{end}} ({{md (join .ParentKey ", ")}}) ON UPDATE {{.OnUpdate}} ON DELETE {{.OnDelete}}
+{{- else}}
+None.
+{{- end}}
+
+## Referenced By
+{{range .References}}
+- [{{md .Table}}]({{page $.Schema .Table}}) ({{md (join .ChildKey ", ")}}) references ({{md (join .ParentKey ", ")}}) ON UPDATE {{.OnUpdate}} ON DELETE {{.OnDelete}}
+{{- else}}
+None.
+{{- end}}
+
+## Triggers
+{{range .Triggers}}
+### {{md .Name}}
+
+` + "```sql" + `
+{{.SQL}}
+` + "```" + `
+{{else}}
+None.
+{{end}}
+## SQL
+
+` + "```sql" + `
+{{.SQL}}
+` + "```" + `
+{{end}}`
+
+// htmlTemplate is the default template for HTML documentation.
+// See DocGenerator for details.
+const htmlTemplate = `
+{{- define "style" -}}
+<style>
+body { font-family: Helvetica, Arial, sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; margin-bottom: 1em; }
+th, td { border: 1px solid #ccc; padding: 0.3em 0.6em; text-align: left; }
+th { background: #eee; }
+pre { background: #f6f6f6; padding: 0.6em; overflow-x: auto; }
+</style>
+{{- end}}
+
+{{- define "index" -}}
+<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Database Schema</title>
+{{template "style"}}
+</head>
+<body>
+<h1>Database Schema</h1>
+{{range .Schemas}}
+<h2>{{.Name}}</h2>
+<ul>
+{{- range .Tables}}
+<li><a href="{{page .Schema .Name}}">{{.Name}}</a></li>
+{{- else}}
+<li>No tables.</li>
+{{- end}}
+</ul>
+{{end -}}
+</body>
+</html>
+{{end}}
+
+{{- define "table" -}}
+<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Schema}}.{{.Name}}</title>
+{{template "style"}}
+</head>
+<body>
+<h1>{{.Schema}}.{{.Name}}</h1>
+<p><a href="{{indexPage}}">Back to index</a></p>
+
+<h2>Columns</h2>
+<table>
+<tr><th>#</th><th>Name</th><th>Type</th><th>Not Null</th><th>Default</th><th>Primary Key</th></tr>
+{{- range .Columns}}
+<tr><td>{{.ID}}</td><td>{{.Name}}</td><td>{{.Type}}</td><td>{{if .NotNull}}Yes{{else}}No{{end}}</td><td>{{str .Default}}</td><td>{{if .PrimaryKey}}{{.PrimaryKey}}{{end}}</td></tr>
+{{- end}}
+</table>
+
+<h2>Indexes</h2>
+{{range .Indexes}}
+<h3>{{.Name}}</h3>
+<p>{{if .IsUnique}}Unique{{else}}Non-unique{{end}} {{.Type}} index{{if .IsPartial}} (partial){{end}}.</p>
+<table>
+<tr><th>Column</th><th>Order</th><th>Collation</th></tr>
+{{- range .Columns}}
+<tr><td>{{if .Name.Valid}}{{.Name.String}}{{else}}<em>expression</em>{{end}}</td><td>{{if .Descending}}DESC{{else}}ASC{{end}}</td><td>{{.Collation}}</td></tr>
+{{- end}}
+</table>
+{{with .SQL}}<pre>{{.}}</pre>{{end}}
+{{else}}
+<p>None.</p>
+{{end}}
+
+<h2>Foreign Keys</h2>
+<ul>
+{{- range .ForeignKeys}}
+<li>({{join .ChildKey ", "}}) references {{if .ParentExists}}<a href="{{page $.Schema .ParentTable}}">{{.ParentTable}}</a>{{else}}{{.ParentTable}}{{end}} ({{join .ParentKey ", "}}) ON UPDATE {{.OnUpdate}} ON DELETE {{.OnDelete}}</li>
+{{- else}}
+<li>None.</li>
+{{- end}}
+</ul>
+
+<h2>Referenced By</h2>
+<ul>
+{{- range .References}}
+<li><a href="{{page $.Schema .Table}}">{{.Table}}</a> ({{join .ChildKey ", "}}) references ({{join .ParentKey ", "}}) ON UPDATE {{.OnUpdate}} ON DELETE {{.OnDelete}}</li>
+{{- else}}
+<li>None.</li>
+{{- end}}
+</ul>
+
+<h2>Triggers</h2>
+{{range .Triggers}}
+<h3>{{.Name}}</h3>
+<pre>{{.SQL}}</pre>
+{{else}}
+<p>None.</p>
+{{end}}
+
+<h2>SQL</h2>
+<pre>{{.SQL}}</pre>
+</body>
+</html>
+{{end}}`
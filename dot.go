@@ -0,0 +1,125 @@
+package sqlitemeta
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+)
+
+// WriteDOT writes an entity-relationship diagram of the tables
+// in a database to w, in the Graphviz DOT language.
+//
+// Each table is drawn as a node listing its columns, their
+// declared types and primary key membership. Each foreign key
+// is drawn as an edge from the child table to the parent table,
+// labelled with the cardinality of the relationship ("N:1" or
+// "1:1") and its ON DELETE action.
+//
+// Use opts to restrict the diagram to a particular Schema or
+// to a subset of its tables. A nil opts diagrams every table
+// in the main database.
+//
+// Render the output with the dot command, e.g.
+//
+//     dot -Tsvg schema.dot > schema.svg
+func WriteDOT(w io.Writer, db Querier, opts *DiagramOptions) error {
+
+	d, err := loadDiagram(db, opts)
+	if err != nil {
+		return fmt.Errorf("could not write DOT diagram: %s", err)
+	}
+
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "digraph %s {\n", dotID(d.name))
+	buf.WriteString("\tgraph [rankdir=LR];\n")
+	buf.WriteString("\tnode [shape=plaintext, fontname=\"Helvetica\"];\n")
+	buf.WriteString("\tedge [fontname=\"Helvetica\", fontsize=10];\n")
+
+	for _, t := range d.tables {
+
+		buf.WriteString("\n")
+		fmt.Fprintf(&buf, "\t%s [label=<\n", dotID(t.name))
+		buf.WriteString("\t\t<table border=\"0\" cellborder=\"1\" cellspacing=\"0\">\n")
+		fmt.Fprintf(&buf, "\t\t<tr><td bgcolor=\"lightgrey\"><b>%s</b></td></tr>\n", html.EscapeString(t.name))
+
+		for _, c := range t.columns {
+
+			label := html.EscapeString(c.Name)
+			if c.Type != "" {
+				label += " " + html.EscapeString(c.Type)
+			}
+			if c.PrimaryKey > 0 {
+				label = "<u>" + label + "</u> (PK)"
+			}
+
+			fmt.Fprintf(&buf, "\t\t<tr><td port=\"%s\" align=\"left\">%s</td></tr>\n", dotPort(c.ID), label)
+		}
+
+		buf.WriteString("\t\t</table>>];\n")
+	}
+
+	if len(d.edges) > 0 {
+		buf.WriteString("\n")
+	}
+
+	for _, e := range d.edges {
+
+		from := dotID(e.child.name)
+		if port, ok := dotColumnPort(e.child.columns, e.childKey); ok {
+			from += ":" + port
+		}
+
+		to := dotID(e.parent.name)
+		if port, ok := dotColumnPort(e.parent.columns, e.parentKey); ok {
+			to += ":" + port
+		}
+
+		cardinality := "N:1"
+		if e.unique {
+			cardinality = "1:1"
+		}
+
+		label := cardinality + `\nON DELETE ` + e.foreignKey.OnDelete.String()
+
+		fmt.Fprintf(&buf, "\t%s -> %s [label=\"%s\"];\n", from, to, label)
+	}
+
+	buf.WriteString("}\n")
+
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// dotID returns s as a quoted DOT identifier.
+func dotID(s string) string {
+	s = strings.Replace(s, `\`, `\\`, -1)
+	s = strings.Replace(s, `"`, `\"`, -1)
+	return `"` + s + `"`
+}
+
+// dotPort returns the port name for the column with the given
+// ID. Ports are named by ID rather than column name to avoid
+// escaping issues.
+func dotPort(id int) string {
+	return fmt.Sprintf("c%d", id)
+}
+
+// dotColumnPort returns the port name of the first column in
+// key, for use as an edge endpoint.
+func dotColumnPort(columns []Column, key []string) (string, bool) {
+
+	if len(key) == 0 {
+		return "", false
+	}
+
+	for _, c := range columns {
+		if sqlower(c.Name) == sqlower(key[0]) {
+			return dotID(dotPort(c.ID)), true
+		}
+	}
+
+	return "", false
+}
@@ -0,0 +1,121 @@
+package sqlitemeta_test
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	"testing"
+
+	meta "github.com/deepilla/sqlitemeta"
+)
+
+var diagramSQL = []string{
+	"DROP TABLE IF EXISTS posts",
+	"DROP TABLE IF EXISTS profiles",
+	"DROP TABLE IF EXISTS users",
+
+	`CREATE TABLE users (
+        id INTEGER PRIMARY KEY,
+        name TEXT NOT NULL
+    )`,
+	`CREATE TABLE profiles (
+        user_id INTEGER NOT NULL UNIQUE REFERENCES users ON DELETE CASCADE,
+        bio TEXT
+    )`,
+	`CREATE TABLE posts (
+        id INTEGER PRIMARY KEY,
+        user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+        title TEXT
+    )`,
+}
+
+func TestWriteDOT(t *testing.T) {
+	testWithDB(t, testWriteDOT)
+}
+
+func testWriteDOT(t *testing.T, db *sql.DB) {
+
+	exec(t, db, diagramSQL)
+
+	data := []struct {
+		Title   string
+		Options *meta.DiagramOptions
+		DOT     string
+	}{
+		{
+			Title: "All Tables",
+			DOT: `digraph "main" {
+	graph [rankdir=LR];
+	node [shape=plaintext, fontname="Helvetica"];
+	edge [fontname="Helvetica", fontsize=10];
+
+	"posts" [label=<
+		<table border="0" cellborder="1" cellspacing="0">
+		<tr><td bgcolor="lightgrey"><b>posts</b></td></tr>
+		<tr><td port="c0" align="left"><u>id INTEGER</u> (PK)</td></tr>
+		<tr><td port="c1" align="left">user_id INTEGER</td></tr>
+		<tr><td port="c2" align="left">title TEXT</td></tr>
+		</table>>];
+
+	"profiles" [label=<
+		<table border="0" cellborder="1" cellspacing="0">
+		<tr><td bgcolor="lightgrey"><b>profiles</b></td></tr>
+		<tr><td port="c0" align="left">user_id INTEGER</td></tr>
+		<tr><td port="c1" align="left">bio TEXT</td></tr>
+		</table>>];
+
+	"users" [label=<
+		<table border="0" cellborder="1" cellspacing="0">
+		<tr><td bgcolor="lightgrey"><b>users</b></td></tr>
+		<tr><td port="c0" align="left"><u>id INTEGER</u> (PK)</td></tr>
+		<tr><td port="c1" align="left">name TEXT</td></tr>
+		</table>>];
+
+	"posts":"c1" -> "users":"c0" [label="N:1\nON DELETE SET NULL"];
+	"profiles":"c0" -> "users":"c0" [label="1:1\nON DELETE CASCADE"];
+}
+`,
+		},
+		{
+			Title: "Table Subset",
+			Options: &meta.DiagramOptions{
+				Schema: meta.Main,
+				Tables: []string{"PROFILES"},
+			},
+			DOT: `digraph "main" {
+	graph [rankdir=LR];
+	node [shape=plaintext, fontname="Helvetica"];
+	edge [fontname="Helvetica", fontsize=10];
+
+	"profiles" [label=<
+		<table border="0" cellborder="1" cellspacing="0">
+		<tr><td bgcolor="lightgrey"><b>profiles</b></td></tr>
+		<tr><td port="c0" align="left">user_id INTEGER</td></tr>
+		<tr><td port="c1" align="left">bio TEXT</td></tr>
+		</table>>];
+}
+`,
+		},
+	}
+
+	for _, test := range data {
+
+		var buf bytes.Buffer
+		if err := meta.WriteDOT(&buf, db, test.Options); err != nil {
+			t.Fatalf("%s: WriteDOT returned error %s", test.Title, err)
+		}
+
+		if got := buf.String(); got != test.DOT {
+			t.Errorf("%s: Expected DOT\n%s\ngot\n%s", test.Title, test.DOT, got)
+		}
+	}
+
+	exp := fmt.Errorf("could not write DOT diagram: unknown table xxxxx")
+	got := meta.WriteDOT(&bytes.Buffer{}, db, &meta.DiagramOptions{
+		Tables: []string{"users", "xxxxx"},
+	})
+
+	if !equalErrors(exp, got) {
+		t.Errorf("Unknown Table: Expected error %v, got %v", exp, got)
+	}
+}
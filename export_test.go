@@ -0,0 +1,23 @@
+package sqlitemeta
+
+// SetLegacyPragmas forces the package to use PRAGMA statements
+// instead of pragma functions, as it would for SQLite versions
+// prior to 3.16.0. It returns a function that restores the
+// previous setting.
+func SetLegacyPragmas(legacy bool) func() {
+
+	prev := legacyPragmas
+	legacyPragmas = legacy
+
+	return func() {
+		legacyPragmas = prev
+	}
+}
+
+// DecodeRecord exposes decodeRecord for testing. Malformed
+// records return ErrBadRecord.
+func DecodeRecord(b []byte) ([]interface{}, error) {
+	return decodeRecord(b)
+}
+
+var ErrBadRecord = errBadRecord
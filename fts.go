@@ -0,0 +1,176 @@
+package sqlitemeta
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// An FTSTable describes a full-text search virtual table.
+type FTSTable struct {
+	Name   string
+	Module string // "fts3", "fts4" or "fts5".
+
+	Columns []FTSColumn
+
+	// Tokenizer is the tokenizer and its arguments, e.g.
+	// "porter unicode61 remove_diacritics 1". If no tokenizer
+	// was specified, Tokenizer is the module's default ("simple"
+	// for FTS3/4, "unicode61" for FTS5).
+	Tokenizer string
+
+	// Prefix lists the lengths of the prefix indexes, if any.
+	Prefix []int
+
+	// Content is the name of the external content table, if
+	// any. It is empty for regular and contentless tables.
+	Content string
+
+	// ContentRowID is the name of the external content table's
+	// rowid column (FTS5 only). It is empty if not specified.
+	ContentRowID string
+
+	// Options contains the options that the table was created
+	// with, keyed by lowercase option name, e.g. "tokenize",
+	// "prefix" or "detail". Values are unquoted. If an option
+	// is specified more than once (e.g. prefix), the values are
+	// separated by spaces.
+	Options map[string]string
+
+	// ShadowTables lists the tables that SQLite uses to store
+	// the full-text index, e.g. "docs_data" and "docs_idx".
+	ShadowTables []string
+}
+
+// An FTSColumn is a column in a full-text search table.
+type FTSColumn struct {
+	Name      string
+	Unindexed bool // True if the column is not added to the full-text index.
+}
+
+// FTS returns a description of the given full-text search table
+// in the main database. Use the Schema.FTS method to query other
+// databases.
+//
+// If the table does not exist or is not a full-text search
+// table, FTS returns nil.
+func FTS(db Querier, tableName string) (*FTSTable, error) {
+	return Main.FTS(db, tableName)
+}
+
+// FTS returns a description of the given full-text search table
+// in this Schema. FTS3, FTS4 and FTS5 tables are supported. The
+// description is derived from the table's CREATE VIRTUAL TABLE
+// statement, so the module itself does not need to be available.
+//
+// If the table does not exist or is not a full-text search
+// table, FTS returns nil.
+func (s *Schema) FTS(db Querier, tableName string) (*FTSTable, error) {
+
+	if s.name == "" {
+		return Main.FTS(db, tableName)
+	}
+
+	objects, err := s.masterObjects(db)
+	if err != nil {
+		return nil, fmt.Errorf("could not get full-text table %s: %s", tableName, err)
+	}
+
+	for _, obj := range objects {
+		if obj.Type == "table" && sqlower(obj.Name) == sqlower(tableName) && obj.SQL.Valid {
+			return parseFTSTable(obj.Name, obj.SQL.String, objects), nil
+		}
+	}
+
+	return nil, nil
+}
+
+// parseFTSTable parses a CREATE VIRTUAL TABLE statement. It
+// returns nil if the statement does not create a full-text
+// search table.
+func parseFTSTable(name, sql string, objects []masterObject) *FTSTable {
+
+	module, args := virtualTableArgs(sql)
+
+	module = sqlower(module)
+	if module != "fts3" && module != "fts4" && module != "fts5" {
+		return nil
+	}
+
+	t := &FTSTable{
+		Name:    name,
+		Module:  module,
+		Options: map[string]string{},
+	}
+
+	for _, arg := range args {
+
+		if len(arg) == 0 {
+			continue
+		}
+
+		// Options have the form "key = value". FTS3/4 also
+		// allows "tokenize porter" without the equals sign.
+		var value []token
+		switch {
+		case len(arg) > 1 && arg[1].isPunct("="):
+			value = arg[2:]
+		case module != "fts5" && arg[0].isWord("tokenize"):
+			value = arg[1:]
+		default:
+			col := FTSColumn{
+				Name: arg[0].value(),
+			}
+			if module == "fts5" && len(arg) > 1 && arg[1].isWord("UNINDEXED") {
+				col.Unindexed = true
+			}
+			t.Columns = append(t.Columns, col)
+			continue
+		}
+
+		var values []string
+		for _, v := range value {
+			values = append(values, v.value())
+		}
+
+		key := sqlower(arg[0].value())
+		val := strings.Join(values, " ")
+
+		if prev, ok := t.Options[key]; ok {
+			val = prev + " " + val
+		}
+		t.Options[key] = val
+	}
+
+	t.Tokenizer = t.Options["tokenize"]
+	if t.Tokenizer == "" {
+		if module == "fts5" {
+			t.Tokenizer = "unicode61"
+		} else {
+			t.Tokenizer = "simple"
+		}
+	}
+
+	for _, f := range strings.FieldsFunc(t.Options["prefix"], func(r rune) bool { return r == ' ' || r == ',' }) {
+		if n, err := strconv.Atoi(f); err == nil {
+			t.Prefix = append(t.Prefix, n)
+		}
+	}
+
+	t.Content = t.Options["content"]
+	t.ContentRowID = t.Options["content_rowid"]
+
+	// FTS4 specifies unindexed columns with the notindexed
+	// option, which may appear more than once.
+	for _, colName := range strings.Fields(t.Options["notindexed"]) {
+		for i := range t.Columns {
+			if sqlower(t.Columns[i].Name) == sqlower(colName) {
+				t.Columns[i].Unindexed = true
+			}
+		}
+	}
+
+	t.ShadowTables = shadowTables(name, module, objects)
+
+	return t
+}
@@ -0,0 +1,152 @@
+package sqlitemeta_test
+
+import (
+	"database/sql"
+	"reflect"
+	"strings"
+	"testing"
+
+	meta "github.com/deepilla/sqlitemeta"
+)
+
+func TestFTS4(t *testing.T) {
+	testWithDB(t, testFTS4)
+}
+
+func testFTS4(t *testing.T, db *sql.DB) {
+
+	exec(t, db, []string{
+		"DROP TABLE IF EXISTS docs",
+		"DROP TABLE IF EXISTS notes",
+		"DROP TABLE IF EXISTS plain",
+		"CREATE TABLE docs (id INTEGER PRIMARY KEY, title TEXT, body TEXT, tag TEXT)",
+		"CREATE VIRTUAL TABLE notes USING fts4(title, body TEXT, tag, tokenize=porter, prefix=\"2,4\", notindexed=tag, content=\"docs\")",
+		"CREATE TABLE plain (x)",
+	})
+
+	_, err := db.Exec("CREATE VIRTUAL TABLE IF NOT EXISTS simple USING fts3(content)")
+	if err != nil && strings.Contains(err.Error(), "no such module") {
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Fatalf("Could not create FTS3 table: %s", err)
+	}
+	defer exec(t, db, []string{"DROP TABLE simple"})
+
+	tbl, err := meta.FTS(db, "NOTES")
+	if err != nil {
+		t.Fatalf("FTS returned error %s", err)
+	}
+
+	exp := &meta.FTSTable{
+		Name:   "notes",
+		Module: "fts4",
+		Columns: []meta.FTSColumn{
+			{Name: "title"},
+			{Name: "body"},
+			{Name: "tag", Unindexed: true},
+		},
+		Tokenizer: "porter",
+		Prefix:    []int{2, 4},
+		Content:   "docs",
+		Options: map[string]string{
+			"tokenize":   "porter",
+			"prefix":     "2,4",
+			"notindexed": "tag",
+			"content":    "docs",
+		},
+		ShadowTables: []string{"notes_segments", "notes_segdir", "notes_docsize", "notes_stat"},
+	}
+
+	if !reflect.DeepEqual(exp, tbl) {
+		t.Errorf("Expected FTS table %+v, got %+v", exp, tbl)
+	}
+
+	tbl, err = meta.FTS(db, "simple")
+	if err != nil {
+		t.Fatalf("FTS returned error %s", err)
+	}
+
+	exp = &meta.FTSTable{
+		Name:   "simple",
+		Module: "fts3",
+		Columns: []meta.FTSColumn{
+			{Name: "content"},
+		},
+		Tokenizer:    "simple",
+		Options:      map[string]string{},
+		ShadowTables: []string{"simple_content", "simple_segments", "simple_segdir"},
+	}
+
+	if !reflect.DeepEqual(exp, tbl) {
+		t.Errorf("Expected FTS table %+v, got %+v", exp, tbl)
+	}
+
+	for _, name := range []string{"plain", "notes_segdir", "xxxxx"} {
+		tbl, err = meta.FTS(db, name)
+		if err != nil {
+			t.Fatalf("FTS returned error %s", err)
+		}
+		if tbl != nil {
+			t.Errorf("Expected nil for table %s, got %+v", name, tbl)
+		}
+	}
+
+	_, err = meta.DB("xxxxx").FTS(db, "notes")
+	if exp := "could not get full-text table notes: unknown database 'xxxxx'"; err == nil || err.Error() != exp {
+		t.Errorf("Expected error %q, got %v", exp, err)
+	}
+}
+
+func TestFTS5(t *testing.T) {
+	testWithDB(t, testFTS5)
+}
+
+func testFTS5(t *testing.T, db *sql.DB) {
+
+	exec(t, db, []string{
+		"DROP TABLE IF EXISTS articles",
+		"DROP TABLE IF EXISTS search",
+		"CREATE TABLE articles (article_id INTEGER PRIMARY KEY, title TEXT, body TEXT, url TEXT)",
+	})
+
+	_, err := db.Exec("CREATE VIRTUAL TABLE search USING fts5(title, body, url UNINDEXED, content='articles', content_rowid='article_id', tokenize = 'porter unicode61 remove_diacritics 1', prefix = 2, prefix = 3, detail = column)")
+	if err != nil && strings.Contains(err.Error(), "no such module") {
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Fatalf("Could not create FTS5 table: %s", err)
+	}
+	defer exec(t, db, []string{"DROP TABLE search"})
+
+	tbl, err := meta.FTS(db, "search")
+	if err != nil {
+		t.Fatalf("FTS returned error %s", err)
+	}
+
+	exp := &meta.FTSTable{
+		Name:   "search",
+		Module: "fts5",
+		Columns: []meta.FTSColumn{
+			{Name: "title"},
+			{Name: "body"},
+			{Name: "url", Unindexed: true},
+		},
+		Tokenizer:    "porter unicode61 remove_diacritics 1",
+		Prefix:       []int{2, 3},
+		Content:      "articles",
+		ContentRowID: "article_id",
+		Options: map[string]string{
+			"content":       "articles",
+			"content_rowid": "article_id",
+			"tokenize":      "porter unicode61 remove_diacritics 1",
+			"prefix":        "2 3",
+			"detail":        "column",
+		},
+		ShadowTables: []string{"search_data", "search_idx", "search_docsize", "search_config"},
+	}
+
+	if !reflect.DeepEqual(exp, tbl) {
+		t.Errorf("Expected FTS table %+v, got %+v", exp, tbl)
+	}
+}
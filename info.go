@@ -0,0 +1,184 @@
+package sqlitemeta
+
+import (
+	"context"
+	"fmt"
+)
+
+// AutoVacuumMode describes when SQLite reclaims the space used
+// by deleted content.
+type AutoVacuumMode uint
+
+const (
+	// AutoVacuumNone means that free pages are only reclaimed
+	// by running VACUUM.
+	AutoVacuumNone AutoVacuumMode = iota
+
+	// AutoVacuumFull means that free pages are reclaimed at
+	// every commit.
+	AutoVacuumFull
+
+	// AutoVacuumIncremental means that free pages are reclaimed
+	// by running PRAGMA incremental_vacuum.
+	AutoVacuumIncremental
+)
+
+// String returns the SQL name of an AutoVacuumMode, e.g. "FULL".
+func (m AutoVacuumMode) String() string {
+	switch m {
+	case AutoVacuumNone:
+		return "NONE"
+	case AutoVacuumFull:
+		return "FULL"
+	case AutoVacuumIncremental:
+		return "INCREMENTAL"
+	default:
+		return fmt.Sprintf("AutoVacuumMode(%d)", uint(m))
+	}
+}
+
+// SynchronousMode describes how carefully SQLite syncs data to
+// disk.
+type SynchronousMode uint
+
+const (
+	// SynchronousOff means that SQLite leaves syncing to the
+	// operating system.
+	SynchronousOff SynchronousMode = iota
+
+	// SynchronousNormal means that SQLite syncs at the most
+	// critical moments.
+	SynchronousNormal
+
+	// SynchronousFull means that SQLite syncs whenever needed
+	// to ensure that a power failure cannot corrupt the
+	// database.
+	SynchronousFull
+
+	// SynchronousExtra is like SynchronousFull but also syncs
+	// the directory containing a rollback journal after the
+	// journal is unlinked.
+	SynchronousExtra
+)
+
+// String returns the SQL name of a SynchronousMode, e.g.
+// "NORMAL".
+func (m SynchronousMode) String() string {
+	switch m {
+	case SynchronousOff:
+		return "OFF"
+	case SynchronousNormal:
+		return "NORMAL"
+	case SynchronousFull:
+		return "FULL"
+	case SynchronousExtra:
+		return "EXTRA"
+	default:
+		return fmt.Sprintf("SynchronousMode(%d)", uint(m))
+	}
+}
+
+// DatabaseInfo holds database-wide properties, as reported by
+// SQLite's PRAGMA statements.
+//
+// See https://sqlite.org/pragma.html for more on each of these
+// properties.
+type DatabaseInfo struct {
+	Name string
+	File string // Empty for temporary and in-memory databases.
+
+	PageSize      int64
+	PageCount     int64
+	FreelistCount int64 // The number of unused pages.
+
+	// Encoding is the text encoding of the database, e.g.
+	// "UTF-8". It is the same for every database attached to
+	// a connection.
+	Encoding string
+
+	UserVersion   int64
+	ApplicationID int64
+	SchemaVersion int64 // Incremented whenever the schema changes.
+
+	JournalMode string // e.g. "delete" or "wal".
+	AutoVacuum  AutoVacuumMode
+	Synchronous SynchronousMode
+}
+
+// Info returns database-wide properties for the main database.
+// Use the Schema.Info method to query other databases.
+func Info(db Querier) (*DatabaseInfo, error) {
+	return Main.Info(db)
+}
+
+// Info returns database-wide properties for this Schema. If the
+// temp database has not been created yet (i.e. there are no
+// temporary objects), Info returns zero values for it.
+func (s *Schema) Info(db Querier) (*DatabaseInfo, error) {
+
+	if s.name == "" {
+		return Main.Info(db)
+	}
+
+	databases, err := databaseList(db)
+	if err != nil {
+		return nil, fmt.Errorf("could not get info for database %s: %s", s.name, err)
+	}
+
+	var info *DatabaseInfo
+	for _, d := range databases {
+		if sqlower(d.Name) == sqlower(s.name) {
+			info = &DatabaseInfo{
+				Name: d.Name,
+				File: d.File,
+			}
+		}
+	}
+
+	// The temp database isn't listed until a temporary object
+	// is created. Querying its properties would create it, so
+	// report an empty database instead.
+	if info == nil && sqlower(s.name) == "temp" {
+		return &DatabaseInfo{
+			Name: "temp",
+		}, nil
+	}
+
+	if info == nil {
+		return nil, fmt.Errorf("could not get info for database %s: unknown database '%s'", s.name, s.name)
+	}
+
+	var autoVacuum, synchronous uint
+
+	pragmas := []struct {
+		Name string
+		Dest interface{}
+	}{
+		{"page_size", &info.PageSize},
+		{"page_count", &info.PageCount},
+		{"freelist_count", &info.FreelistCount},
+		{"encoding", &info.Encoding},
+		{"user_version", &info.UserVersion},
+		{"application_id", &info.ApplicationID},
+		{"schema_version", &info.SchemaVersion},
+		{"journal_mode", &info.JournalMode},
+		{"auto_vacuum", &autoVacuum},
+		{"synchronous", &synchronous},
+	}
+
+	for _, p := range pragmas {
+
+		// The schema name has been verified above so it's safe
+		// to insert into the SQL.
+		q := fmt.Sprintf("PRAGMA %s.%s", quoteIdent(info.Name), p.Name)
+
+		if err := db.QueryRowContext(context.Background(), q).Scan(p.Dest); err != nil {
+			return nil, fmt.Errorf("could not get info for database %s: %s", s.name, err)
+		}
+	}
+
+	info.AutoVacuum = AutoVacuumMode(autoVacuum)
+	info.Synchronous = SynchronousMode(synchronous)
+
+	return info, nil
+}
@@ -0,0 +1,101 @@
+package sqlitemeta_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	meta "github.com/deepilla/sqlitemeta"
+)
+
+func TestInfo(t *testing.T) {
+	testWithDB(t, testInfo)
+}
+
+func testInfo(t *testing.T, db *sql.DB) {
+
+	exec(t, db, []string{
+		"DROP TABLE IF EXISTS test",
+		"PRAGMA user_version = 7",
+		"PRAGMA application_id = 1234",
+		"CREATE TABLE test (x)",
+		"ATTACH DATABASE ':memory:' AS aux",
+		"PRAGMA aux.user_version = 3",
+		"PRAGMA aux.synchronous = OFF",
+		"CREATE TABLE aux.test (x)",
+	})
+
+	info, err := meta.Info(db)
+	if err != nil {
+		t.Fatalf("Info returned error %s", err)
+	}
+
+	if info.Name != "main" || info.UserVersion != 7 || info.ApplicationID != 1234 {
+		t.Errorf("Expected main with user version 7 and application id 1234, got %s with %d and %d", info.Name, info.UserVersion, info.ApplicationID)
+	}
+	if info.PageSize <= 0 || info.PageCount <= 0 || info.SchemaVersion <= 0 {
+		t.Errorf("Expected positive page size, page count and schema version, got %d, %d and %d", info.PageSize, info.PageCount, info.SchemaVersion)
+	}
+	if info.Encoding != "UTF-8" {
+		t.Errorf("Expected encoding UTF-8, got %s", info.Encoding)
+	}
+	if info.JournalMode == "" {
+		t.Errorf("Expected a journal mode")
+	}
+
+	info, err = meta.DB("AUX").Info(db)
+	if err != nil {
+		t.Fatalf("Info returned error %s", err)
+	}
+
+	if info.Name != "aux" || info.File != "" || info.UserVersion != 3 || info.ApplicationID != 0 {
+		t.Errorf("Expected in-memory aux with user version 3 and application id 0, got %s (%q) with %d and %d", info.Name, info.File, info.UserVersion, info.ApplicationID)
+	}
+	if info.JournalMode != "memory" {
+		t.Errorf("Expected journal mode memory, got %s", info.JournalMode)
+	}
+	if info.Synchronous != meta.SynchronousOff {
+		t.Errorf("Expected synchronous OFF, got %s", info.Synchronous)
+	}
+
+	_, err = meta.DB("xxxxx").Info(db)
+	if exp := "could not get info for database xxxxx: unknown database 'xxxxx'"; err == nil || err.Error() != exp {
+		t.Errorf("Expected error %q, got %v", exp, err)
+	}
+}
+
+func TestInfoTemp(t *testing.T) {
+
+	// The temp database isn't listed until a temporary object
+	// is created, so use a fresh database.
+	db, close, err := memoryDB()
+	if err != nil {
+		t.Fatalf("Could not open db: %s", err)
+	}
+	defer close()
+
+	conn, err := db.Conn(context.Background())
+	if err != nil {
+		t.Fatalf("Could not get connection: %s", err)
+	}
+	defer conn.Close()
+
+	info, err := meta.Temp.Info(conn)
+	if err != nil {
+		t.Fatalf("Info returned error %s", err)
+	}
+
+	if info.Name != "temp" || info.File != "" || info.PageCount != 0 || info.UserVersion != 0 {
+		t.Errorf("Expected empty temp database, got %+v", info)
+	}
+
+	// Info should not create the temp database.
+	names, err := meta.SchemaNames(conn)
+	if err != nil {
+		t.Fatalf("SchemaNames returned error %s", err)
+	}
+
+	if exp := []string{"main"}; !equalStringSlices(exp, names) {
+		t.Errorf("Expected schemas %q, got %q", exp, names)
+	}
+}
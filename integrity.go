@@ -0,0 +1,211 @@
+package sqlitemeta
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// An IntegrityProblem is a problem reported by IntegrityCheck or
+// QuickCheck.
+type IntegrityProblem struct {
+	Message string // The message returned by SQLite.
+
+	// Table and Index identify the object affected by the
+	// problem, if known. If Index is set, Table is the table
+	// that the index belongs to.
+	Table string
+	Index string
+}
+
+// IntegrityOptions controls the behaviour of IntegrityCheck and
+// QuickCheck.
+type IntegrityOptions struct {
+	// Table restricts the check to the named table and its
+	// indexes. If empty, the whole database is checked.
+	// Requires SQLite 3.33.0 or later.
+	Table string
+
+	// MaxErrors is the maximum number of problems to report.
+	// If zero, SQLite's default of 100 is used.
+	MaxErrors int
+}
+
+// IntegrityCheck runs PRAGMA integrity_check on the main
+// database and returns the problems found. If the database is
+// healthy, IntegrityCheck returns an empty slice. Use the
+// Schema.IntegrityCheck method to check other databases.
+func IntegrityCheck(db Querier, opts *IntegrityOptions) ([]IntegrityProblem, error) {
+	return Main.IntegrityCheck(db, opts)
+}
+
+// IntegrityCheck runs PRAGMA integrity_check on this Schema and
+// returns the problems found. If the database is healthy,
+// IntegrityCheck returns an empty slice.
+//
+// An integrity check looks for out-of-order records, missing
+// pages, malformed records, missing or surplus index entries,
+// and UNIQUE, CHECK and NOT NULL constraint errors. It can take
+// a long time to run on large databases.
+func (s *Schema) IntegrityCheck(db Querier, opts *IntegrityOptions) ([]IntegrityProblem, error) {
+	return s.check(db, "integrity_check", opts)
+}
+
+// QuickCheck runs PRAGMA quick_check on the main database and
+// returns the problems found. If the database is healthy,
+// QuickCheck returns an empty slice. Use the Schema.QuickCheck
+// method to check other databases.
+func QuickCheck(db Querier, opts *IntegrityOptions) ([]IntegrityProblem, error) {
+	return Main.QuickCheck(db, opts)
+}
+
+// QuickCheck runs PRAGMA quick_check on this Schema and returns
+// the problems found. If the database is healthy, QuickCheck
+// returns an empty slice.
+//
+// A quick check is similar to an integrity check but does not
+// verify UNIQUE constraints or that index content matches table
+// content. It runs much faster than an integrity check.
+func (s *Schema) QuickCheck(db Querier, opts *IntegrityOptions) ([]IntegrityProblem, error) {
+	return s.check(db, "quick_check", opts)
+}
+
+func (s *Schema) check(db Querier, pragma string, opts *IntegrityOptions) ([]IntegrityProblem, error) {
+
+	if s.name == "" {
+		return Main.check(db, pragma, opts)
+	}
+
+	if opts == nil {
+		opts = &IntegrityOptions{}
+	}
+
+	prefix, err := s.qualify(db, "")
+	if err != nil {
+		return nil, fmt.Errorf("could not run %s: %s", pragma, err)
+	}
+
+	arg := ""
+	switch {
+	case opts.Table != "":
+		arg = "(" + quoteIdent(opts.Table) + ")"
+	case opts.MaxErrors > 0:
+		arg = "(" + strconv.Itoa(opts.MaxErrors) + ")"
+	}
+
+	messages, err := queryStrings(db, "PRAGMA "+prefix+pragma+arg)
+	if err != nil {
+		return nil, fmt.Errorf("could not run %s: %s", pragma, err)
+	}
+
+	if len(messages) == 1 && messages[0] == "ok" {
+		return []IntegrityProblem{}, nil
+	}
+
+	// A table argument can't be combined with a limit.
+	if opts.MaxErrors > 0 && len(messages) > opts.MaxErrors {
+		messages = messages[:opts.MaxErrors]
+	}
+
+	objects, err := s.rootPages(db)
+	if err != nil {
+		return nil, fmt.Errorf("could not run %s: %s", pragma, err)
+	}
+
+	var problems []IntegrityProblem
+
+	for _, msg := range messages {
+
+		p := IntegrityProblem{
+			Message: strings.TrimSpace(databaseHeader.ReplaceAllString(msg, "")),
+		}
+
+		if obj, ok := objects.find(p.Message); ok {
+			if obj.Type == "index" {
+				p.Index = obj.Name
+			}
+			p.Table = obj.Table
+		}
+
+		problems = append(problems, p)
+	}
+
+	return problems, nil
+}
+
+var (
+	// databaseHeader precedes the first message for each
+	// database, e.g. "*** in database main ***".
+	databaseHeader = regexp.MustCompile(`^\*\*\* in database .* \*\*\*\n`)
+
+	// These patterns extract object names from messages, e.g.
+	//
+	//     row 1 missing from index idx_x
+	//     CHECK constraint failed in t
+	//     NULL value in t.x
+	//     Tree 2 page 2 cell 0: ...
+	//
+	indexPattern    = regexp.MustCompile(`\bindex (\S+)$`)
+	tablePattern    = regexp.MustCompile(`\b(?:failed|value) in ([^\s.]+)(?:\.\S+)?$`)
+	rootPagePattern = regexp.MustCompile(`\bTree (\d+) page`)
+)
+
+type rootPageObject struct {
+	Type     string
+	Name     string
+	Table    string
+	RootPage int
+}
+
+type rootPageObjects []rootPageObject
+
+// rootPages returns the tables and indexes in this Schema, along
+// with their root page numbers.
+func (s *Schema) rootPages(db Querier) (rootPageObjects, error) {
+
+	master, err := s.masterTable(db)
+	if err != nil {
+		return nil, err
+	}
+
+	q := fmt.Sprintf("SELECT type, name, tbl_name, rootpage FROM %s WHERE type IN ('table', 'index')", master)
+
+	var obj rootPageObject
+	var objects rootPageObjects
+
+	err = scanRows(db, q, nil, func() {
+		objects = append(objects, obj)
+	}, &obj.Type, &obj.Name, &obj.Table, &obj.RootPage)
+	if err != nil {
+		return nil, err
+	}
+
+	return objects, nil
+}
+
+// find returns the object referred to in an integrity check
+// message.
+func (objects rootPageObjects) find(msg string) (rootPageObject, bool) {
+
+	if m := rootPagePattern.FindStringSubmatch(msg); m != nil {
+		page, _ := strconv.Atoi(m[1])
+		for _, obj := range objects {
+			if obj.RootPage == page {
+				return obj, true
+			}
+		}
+	}
+
+	for _, p := range []*regexp.Regexp{indexPattern, tablePattern} {
+		if m := p.FindStringSubmatch(msg); m != nil {
+			for _, obj := range objects {
+				if sqlower(obj.Name) == sqlower(m[1]) {
+					return obj, true
+				}
+			}
+		}
+	}
+
+	return rootPageObject{}, false
+}
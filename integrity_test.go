@@ -0,0 +1,125 @@
+package sqlitemeta_test
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+
+	meta "github.com/deepilla/sqlitemeta"
+)
+
+func TestIntegrityCheck(t *testing.T) {
+	testWithDB(t, testIntegrityCheck)
+}
+
+func testIntegrityCheck(t *testing.T, db *sql.DB) {
+
+	exec(t, db, []string{
+		"DROP TABLE IF EXISTS checked",
+		"DROP TABLE IF EXISTS other",
+		"CREATE TABLE checked (x INTEGER CHECK (x > 0))",
+		"CREATE TABLE other (y INTEGER CHECK (y > 0))",
+		"CREATE INDEX checked_x ON checked (x)",
+		"ATTACH DATABASE ':memory:' AS aux",
+		"CREATE TABLE aux.healthy (z)",
+		"INSERT INTO aux.healthy VALUES (1)",
+		"INSERT INTO checked VALUES (1)",
+	})
+
+	checks := []struct {
+		Name  string
+		Func  func(meta.Querier, *meta.IntegrityOptions) ([]meta.IntegrityProblem, error)
+		DB    func(*meta.Schema, meta.Querier, *meta.IntegrityOptions) ([]meta.IntegrityProblem, error)
+		Error string
+	}{
+		{
+			Name:  "IntegrityCheck",
+			Func:  meta.IntegrityCheck,
+			DB:    (*meta.Schema).IntegrityCheck,
+			Error: "could not run integrity_check: unknown database 'xxxxx'",
+		},
+		{
+			Name:  "QuickCheck",
+			Func:  meta.QuickCheck,
+			DB:    (*meta.Schema).QuickCheck,
+			Error: "could not run quick_check: unknown database 'xxxxx'",
+		},
+	}
+
+	for _, c := range checks {
+
+		problems, err := c.Func(db, nil)
+		if err != nil {
+			t.Fatalf("%s returned error %s", c.Name, err)
+		}
+		if problems == nil || len(problems) != 0 {
+			t.Errorf("%s: Expected empty slice for a healthy database, got %v", c.Name, problems)
+		}
+
+		_, err = c.DB(meta.DB("xxxxx"), db, nil)
+		if err == nil || err.Error() != c.Error {
+			t.Errorf("%s: Expected error %q, got %v", c.Name, c.Error, err)
+		}
+	}
+
+	exec(t, db, []string{
+		"PRAGMA ignore_check_constraints = ON",
+		"INSERT INTO checked VALUES (-1), (-2), (-3)",
+		"INSERT INTO other VALUES (-1)",
+		"PRAGMA ignore_check_constraints = OFF",
+	})
+
+	for _, c := range checks {
+
+		problems, err := c.Func(db, nil)
+		if err != nil {
+			t.Fatalf("%s returned error %s", c.Name, err)
+		}
+
+		counts := map[string]int{}
+		for _, p := range problems {
+			if !strings.Contains(p.Message, "CHECK constraint failed") {
+				t.Errorf("%s: Expected CHECK constraint failure, got %q", c.Name, p.Message)
+			}
+			if p.Index != "" {
+				t.Errorf("%s: Expected no index for %q, got %s", c.Name, p.Message, p.Index)
+			}
+			counts[p.Table]++
+		}
+		if counts["checked"] != 3 || counts["other"] != 1 || len(counts) != 2 {
+			t.Errorf("%s: Expected 3 problems in checked and 1 in other, got %v", c.Name, counts)
+		}
+
+		problems, err = c.Func(db, &meta.IntegrityOptions{MaxErrors: 2})
+		if err != nil {
+			t.Fatalf("%s returned error %s", c.Name, err)
+		}
+		if len(problems) != 2 {
+			t.Errorf("%s: Expected 2 problems with MaxErrors 2, got %d", c.Name, len(problems))
+		}
+
+		problems, err = c.Func(db, &meta.IntegrityOptions{Table: "OTHER"})
+		if err != nil {
+			t.Fatalf("%s returned error %s", c.Name, err)
+		}
+		if len(problems) != 1 || problems[0].Table != "other" {
+			t.Errorf("%s: Expected 1 problem in other, got %v", c.Name, problems)
+		}
+
+		problems, err = c.Func(db, &meta.IntegrityOptions{Table: "checked", MaxErrors: 1})
+		if err != nil {
+			t.Fatalf("%s returned error %s", c.Name, err)
+		}
+		if len(problems) != 1 || problems[0].Table != "checked" {
+			t.Errorf("%s: Expected 1 problem in checked, got %v", c.Name, problems)
+		}
+
+		problems, err = c.DB(meta.DB("aux"), db, nil)
+		if err != nil {
+			t.Fatalf("%s returned error %s", c.Name, err)
+		}
+		if len(problems) != 0 {
+			t.Errorf("%s: Expected no problems in aux, got %v", c.Name, problems)
+		}
+	}
+}
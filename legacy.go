@@ -0,0 +1,115 @@
+package sqlitemeta
+
+import "sort"
+
+// The functions in this file query metadata using PRAGMA
+// statements for SQLite libraries that don't support pragma
+// functions (i.e. versions prior to 3.16.0). They return the
+// same results as the equivalent pragma function queries.
+//
+// Each function takes a prefix that qualifies the PRAGMA with a
+// schema name. Object names are quoted before being inserted
+// into the SQL.
+
+func legacyDatabaseRows(db Querier) ([]databaseRow, error) {
+
+	var seq int
+	var r databaseRow
+	var databases []databaseRow
+
+	err := scanRows(db, "PRAGMA database_list", nil, func() {
+		databases = append(databases, r)
+	}, &seq, &r.Name, &r.File)
+	if err != nil {
+		return nil, err
+	}
+
+	return databases, nil
+}
+
+func legacyForeignKeyRows(db Querier, prefix, tableName string) ([]foreignKeyRow, error) {
+
+	var r legacyForeignKeyRow
+	var match string
+	var fks byForeignKeyOrder
+
+	err := scanRows(db, "PRAGMA "+prefix+"foreign_key_list("+quoteIdent(tableName)+")", nil, func() {
+		fks = append(fks, r)
+	}, &r.ID, &r.seq, &r.Table, &r.From, &r.To, &r.OnUpdate, &r.OnDelete, &match)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Sort(fks)
+
+	var result []foreignKeyRow
+	for _, fk := range fks {
+		result = append(result, fk.foreignKeyRow)
+	}
+
+	return result, nil
+}
+
+type legacyForeignKeyRow struct {
+	foreignKeyRow
+	seq int
+}
+
+type byForeignKeyOrder []legacyForeignKeyRow
+
+func (r byForeignKeyOrder) Len() int      { return len(r) }
+func (r byForeignKeyOrder) Swap(i, j int) { r[i], r[j] = r[j], r[i] }
+func (r byForeignKeyOrder) Less(i, j int) bool {
+	if r[i].ID != r[j].ID {
+		return r[i].ID < r[j].ID
+	}
+	return r[i].seq < r[j].seq
+}
+
+func legacyIndexRows(db Querier, prefix, tableName string) ([]indexRow, error) {
+
+	var seq int
+	var idx indexRow
+	var indexes []indexRow
+
+	err := scanRows(db, "PRAGMA "+prefix+"index_list("+quoteIdent(tableName)+")", nil, func() {
+		indexes = append(indexes, idx)
+	}, &seq, &idx.Name, &idx.Unique, &idx.Type, &idx.Partial)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []indexRow
+
+	for _, idx := range indexes {
+
+		var rank, tableRank int
+		r := idx
+
+		err := scanRows(db, "PRAGMA "+prefix+"index_info("+quoteIdent(idx.Name)+")", nil, func() {
+			rows = append(rows, r)
+		}, &rank, &tableRank, &r.ColumnName)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return rows, nil
+}
+
+func legacyIndexColumns(db Querier, prefix, indexName string, includeAux bool) ([]IndexColumn, error) {
+
+	var c IndexColumn
+	var columns []IndexColumn
+
+	err := scanRows(db, "PRAGMA "+prefix+"index_xinfo("+quoteIdent(indexName)+")", nil, func() {
+		if c.IsKey || includeAux {
+			columns = append(columns, c)
+		}
+	}, &c.Rank, &c.TableRank, &c.Name, &c.Descending, &c.Collation, &c.IsKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return columns, nil
+}
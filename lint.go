@@ -0,0 +1,428 @@
+package sqlitemeta
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// A Severity indicates the seriousness of a lint Finding.
+type Severity uint
+
+const (
+	// SeverityInfo findings are suggestions that may not apply
+	// to every application.
+	SeverityInfo Severity = iota
+
+	// SeverityWarning findings are likely to cause problems,
+	// e.g. poor performance or unexpected type conversions.
+	SeverityWarning
+
+	// SeverityError findings are almost certainly mistakes.
+	SeverityError
+)
+
+// String returns the name of a Severity, e.g. "warning".
+func (s Severity) String() string {
+	switch s {
+	case SeverityInfo:
+		return "info"
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	default:
+		return fmt.Sprintf("Severity(%d)", uint(s))
+	}
+}
+
+// A LintRule identifies one of the checks performed by Lint.
+type LintRule string
+
+const (
+	// RuleUnindexedForeignKey reports foreign keys whose child
+	// key is not the leftmost part of any index. Without such
+	// an index, SQLite must scan the child table whenever a
+	// parent row is updated or deleted.
+	RuleUnindexedForeignKey LintRule = "unindexed-foreign-key"
+
+	// RuleNoPrimaryKey reports tables with no PRIMARY KEY.
+	RuleNoPrimaryKey LintRule = "no-primary-key"
+
+	// RuleUntypedColumn reports columns with no declared type.
+	RuleUntypedColumn LintRule = "untyped-column"
+
+	// RuleForeignKeyTypeMismatch reports foreign key columns
+	// whose declared type differs from that of the parent key
+	// column they refer to.
+	RuleForeignKeyTypeMismatch LintRule = "foreign-key-type-mismatch"
+
+	// RuleNeedlessAutoincrement reports tables declared with
+	// AUTOINCREMENT that don't appear to need it. AUTOINCREMENT
+	// imposes extra CPU, memory and disk overhead and is only
+	// needed to prevent the reuse of rowids from previously
+	// deleted rows. Tables that are referenced by a foreign key,
+	// or whose AUTOINCREMENT sequence is ahead of their largest
+	// rowid (i.e. rows have been deleted whose rowids would
+	// otherwise be reused), are assumed to need it.
+	RuleNeedlessAutoincrement LintRule = "needless-autoincrement"
+)
+
+// LintRules lists every rule supported by Lint, in the order
+// in which they are applied.
+var LintRules = []LintRule{
+	RuleUnindexedForeignKey,
+	RuleNoPrimaryKey,
+	RuleUntypedColumn,
+	RuleForeignKeyTypeMismatch,
+	RuleNeedlessAutoincrement,
+}
+
+// A Finding is a potential problem reported by Lint.
+type Finding struct {
+	Rule     LintRule
+	Severity Severity
+	Schema   string
+	Object   string // The affected table or column, e.g. "posts" or "posts.user_id".
+	Message  string
+	Fix      string // A suggested fix, e.g. a CREATE INDEX statement.
+}
+
+// String returns a one-line description of a Finding.
+func (f Finding) String() string {
+	return fmt.Sprintf("%s: %s.%s: %s [%s]", f.Severity, f.Schema, f.Object, f.Message, f.Rule)
+}
+
+// LintOptions controls the behaviour of Lint.
+type LintOptions struct {
+	// Schema is the database to check. If nil, the main
+	// database is checked.
+	Schema *Schema
+
+	// Rules lists the rules to apply. If empty, all of the
+	// rules in LintRules are applied.
+	Rules []LintRule
+}
+
+// Lint checks the tables in a database for common schema
+// design problems. Findings are returned in table name order
+// and, for each table, in the order of LintRules.
+//
+// Internal tables (those whose names begin with "sqlite_")
+// and virtual tables are not checked.
+func Lint(db Querier, opts *LintOptions) ([]Finding, error) {
+
+	if opts == nil {
+		opts = &LintOptions{}
+	}
+
+	s := opts.Schema
+	if s == nil {
+		s = Main
+	}
+
+	enabled := map[LintRule]bool{}
+	for _, r := range opts.Rules {
+		enabled[r] = true
+	}
+	for r := range enabled {
+		if lintChecks[r] == nil {
+			return nil, fmt.Errorf("could not lint schema %s: unknown rule %q", s.name, r)
+		}
+	}
+
+	tables, err := s.lintTables(db)
+	if err != nil {
+		return nil, fmt.Errorf("could not lint schema %s: %s", s.name, err)
+	}
+
+	byName := map[string]*lintTable{}
+	for _, t := range tables {
+		byName[sqlower(t.name)] = t
+	}
+
+	var findings []Finding
+
+	for _, t := range tables {
+		for _, r := range LintRules {
+
+			if len(enabled) > 0 && !enabled[r] {
+				continue
+			}
+
+			for _, f := range lintChecks[r](t, byName) {
+				f.Rule = r
+				f.Schema = s.name
+				findings = append(findings, f)
+			}
+		}
+	}
+
+	return findings, nil
+}
+
+// A lintTable holds the metadata for a table that is needed
+// by the lint checks.
+type lintTable struct {
+	schema      string
+	name        string
+	tokens      []token
+	columns     []Column
+	indexes     []Index
+	foreignKeys []ForeignKey
+
+	// sequenceAhead is true if the table's AUTOINCREMENT
+	// sequence is greater than its largest rowid.
+	sequenceAhead bool
+}
+
+func (s *Schema) lintTables(db Querier) ([]*lintTable, error) {
+
+	objects, err := s.masterObjects(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var tables []*lintTable
+
+	for _, obj := range objects {
+
+		if obj.Type != "table" || strings.HasPrefix(sqlower(obj.Name), "sqlite_") || isVirtualTableSQL(obj.SQL.String) {
+			continue
+		}
+
+		t := &lintTable{
+			schema: s.name,
+			name:   obj.Name,
+			tokens: tokenize(obj.SQL.String),
+		}
+
+		if t.columns, err = s.Columns(db, t.name); err != nil {
+			return nil, err
+		}
+		if t.indexes, err = s.Indexes(db, t.name); err != nil {
+			return nil, err
+		}
+		if t.foreignKeys, err = s.ForeignKeys(db, t.name); err != nil {
+			return nil, err
+		}
+		if hasWord(t.tokens, "AUTOINCREMENT") {
+			if t.sequenceAhead, err = s.sequenceAhead(db, t.name); err != nil {
+				return nil, err
+			}
+		}
+
+		tables = append(tables, t)
+	}
+
+	return tables, nil
+}
+
+// sequenceAhead reports whether the AUTOINCREMENT sequence of
+// the given table is greater than its largest rowid.
+func (s *Schema) sequenceAhead(db Querier, tableName string) (bool, error) {
+
+	seq, err := s.Sequence(db, tableName)
+	if err != nil {
+		return false, err
+	}
+
+	table, err := s.qualify(db, quoteIdent(tableName))
+	if err != nil {
+		return false, err
+	}
+
+	var max int64
+	err = db.QueryRowContext(context.Background(), "SELECT COALESCE(MAX(rowid), 0) FROM "+table).Scan(&max)
+	if err != nil {
+		return false, err
+	}
+
+	return seq > max, nil
+}
+
+func (t *lintTable) column(name string) (Column, bool) {
+	for _, c := range t.columns {
+		if sqlower(c.Name) == sqlower(name) {
+			return c, true
+		}
+	}
+	return Column{}, false
+}
+
+// isIndexed reports whether the given columns are the leftmost
+// columns of an index (in any order) or a rowid alias.
+func (t *lintTable) isIndexed(key []string) bool {
+
+	if pk := primaryKeyColumns(t.columns); len(key) == 1 && len(pk) == 1 && sqlower(key[0]) == sqlower(pk[0]) {
+		if c, ok := t.column(pk[0]); ok && strings.EqualFold(c.Type, "INTEGER") {
+			return true
+		}
+	}
+
+	for _, idx := range t.indexes {
+
+		if idx.IsPartial || len(idx.ColumnNames) < len(key) {
+			continue
+		}
+
+		var names []string
+		for _, name := range idx.ColumnNames[:len(key)] {
+			names = append(names, name.String)
+		}
+
+		if equalNameSets(key, names) {
+			return true
+		}
+	}
+
+	return false
+}
+
+type lintCheck func(t *lintTable, tables map[string]*lintTable) []Finding
+
+var lintChecks = map[LintRule]lintCheck{
+	RuleUnindexedForeignKey:    lintUnindexedForeignKey,
+	RuleNoPrimaryKey:           lintNoPrimaryKey,
+	RuleUntypedColumn:          lintUntypedColumn,
+	RuleForeignKeyTypeMismatch: lintForeignKeyTypeMismatch,
+	RuleNeedlessAutoincrement:  lintNeedlessAutoincrement,
+}
+
+func lintUnindexedForeignKey(t *lintTable, tables map[string]*lintTable) []Finding {
+
+	var findings []Finding
+
+	for _, fk := range t.foreignKeys {
+
+		if t.isIndexed(fk.ChildKey) {
+			continue
+		}
+
+		findings = append(findings, Finding{
+			Severity: SeverityWarning,
+			Object:   t.name,
+			Message:  fmt.Sprintf("foreign key (%s) referencing %s is not indexed", strings.Join(fk.ChildKey, ", "), fk.ParentTable),
+			Fix:      createIndexSQL(t.schema, t.name, fk.ChildKey),
+		})
+	}
+
+	return findings
+}
+
+func lintNoPrimaryKey(t *lintTable, tables map[string]*lintTable) []Finding {
+
+	if len(primaryKeyColumns(t.columns)) > 0 {
+		return nil
+	}
+
+	return []Finding{
+		{
+			Severity: SeverityWarning,
+			Object:   t.name,
+			Message:  "table has no primary key",
+			Fix:      "Add a PRIMARY KEY, e.g. an INTEGER PRIMARY KEY column.",
+		},
+	}
+}
+
+func lintUntypedColumn(t *lintTable, tables map[string]*lintTable) []Finding {
+
+	var findings []Finding
+
+	for _, c := range t.columns {
+
+		if strings.TrimSpace(c.Type) != "" {
+			continue
+		}
+
+		findings = append(findings, Finding{
+			Severity: SeverityInfo,
+			Object:   t.name + "." + c.Name,
+			Message:  "column has no declared type",
+			Fix:      "Declare a type (e.g. INTEGER, TEXT, REAL or BLOB) so that the column has the intended affinity.",
+		})
+	}
+
+	return findings
+}
+
+func lintForeignKeyTypeMismatch(t *lintTable, tables map[string]*lintTable) []Finding {
+
+	var findings []Finding
+
+	for _, fk := range t.foreignKeys {
+
+		parent := tables[sqlower(fk.ParentTable)]
+		if parent == nil {
+			continue
+		}
+
+		parentKey := resolveParentKey(fk, parent.columns)
+		if len(parentKey) != len(fk.ChildKey) {
+			continue
+		}
+
+		for i, name := range fk.ChildKey {
+
+			child, ok1 := t.column(name)
+			target, ok2 := parent.column(parentKey[i])
+			if !ok1 || !ok2 || normalizeType(child.Type) == normalizeType(target.Type) {
+				continue
+			}
+
+			findings = append(findings, Finding{
+				Severity: SeverityWarning,
+				Object:   t.name + "." + child.Name,
+				Message:  fmt.Sprintf("column type %q differs from the type of parent key %s.%s (%q)", child.Type, parent.name, target.Name, target.Type),
+				Fix:      fmt.Sprintf("Change the type of %s.%s to %s.", t.name, child.Name, target.Type),
+			})
+		}
+	}
+
+	return findings
+}
+
+func lintNeedlessAutoincrement(t *lintTable, tables map[string]*lintTable) []Finding {
+
+	if !hasWord(t.tokens, "AUTOINCREMENT") || t.sequenceAhead {
+		return nil
+	}
+
+	// Reusing the rowid of a deleted parent row could attach
+	// the wrong rows to it.
+	for _, other := range tables {
+		for _, fk := range other.foreignKeys {
+			if sqlower(fk.ParentTable) == sqlower(t.name) {
+				return nil
+			}
+		}
+	}
+
+	return []Finding{
+		{
+			Severity: SeverityInfo,
+			Object:   t.name,
+			Message:  "table uses AUTOINCREMENT but does not appear to need it",
+			Fix:      "Remove AUTOINCREMENT unless rowids of deleted rows must never be reused. An INTEGER PRIMARY KEY alone assigns unique rowids.",
+		},
+	}
+}
+
+// normalizeType converts a declared type into a canonical form
+// for comparison, e.g. "varchar (255)" becomes "VARCHAR(255)".
+func normalizeType(s string) string {
+
+	var words []string
+	for _, t := range tokenize(s) {
+		words = append(words, strings.ToUpper(t.text))
+	}
+
+	s = strings.Join(words, " ")
+	s = strings.Replace(s, " (", "(", -1)
+	s = strings.Replace(s, "( ", "(", -1)
+	s = strings.Replace(s, " )", ")", -1)
+	s = strings.Replace(s, " ,", ",", -1)
+
+	return s
+}
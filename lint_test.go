@@ -0,0 +1,131 @@
+package sqlitemeta_test
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+
+	meta "github.com/deepilla/sqlitemeta"
+)
+
+func TestLint(t *testing.T) {
+	testWithDB(t, testLint)
+}
+
+func testLint(t *testing.T, db *sql.DB) {
+
+	exec(t, db, []string{
+		"DROP TABLE IF EXISTS comments",
+		"DROP TABLE IF EXISTS posts",
+		"DROP TABLE IF EXISTS authors",
+		"DROP TABLE IF EXISTS log",
+		"DROP TABLE IF EXISTS events",
+		"DROP TABLE IF EXISTS jobs",
+
+		`CREATE TABLE authors (
+            id INTEGER PRIMARY KEY AUTOINCREMENT,
+            name TEXT NOT NULL
+        )`,
+		`CREATE TABLE posts (
+            id INTEGER PRIMARY KEY,
+            author_id INTEGER NOT NULL REFERENCES authors(id)
+        )`,
+		"CREATE INDEX idx_posts_author ON posts(author_id)",
+		`CREATE TABLE comments (
+            id INTEGER PRIMARY KEY,
+            post_id TEXT REFERENCES posts,
+            author_id integer REFERENCES authors(id)
+        )`,
+		"CREATE INDEX idx_comments_author ON comments(author_id, post_id)",
+		"CREATE TABLE log (message TEXT, extra)",
+
+		// Only events doesn't need AUTOINCREMENT: authors is
+		// referenced by foreign keys and the largest rowid of
+		// jobs has been deleted.
+		"CREATE TABLE events (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT)",
+		"INSERT INTO events (name) VALUES ('a'), ('b')",
+		"CREATE TABLE jobs (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT)",
+		"INSERT INTO jobs (name) VALUES ('a'), ('b')",
+		"DELETE FROM jobs WHERE id = 2",
+	})
+
+	finding := func(rule meta.LintRule, severity meta.Severity, object, message, fix string) meta.Finding {
+		return meta.Finding{
+			Rule:     rule,
+			Severity: severity,
+			Schema:   "main",
+			Object:   object,
+			Message:  message,
+			Fix:      fix,
+		}
+	}
+
+	unindexed := finding(meta.RuleUnindexedForeignKey, meta.SeverityWarning, "comments",
+		"foreign key (post_id) referencing posts is not indexed",
+		`CREATE INDEX "main"."idx_comments_post_id" ON "comments" ("post_id");`)
+
+	mismatch := finding(meta.RuleForeignKeyTypeMismatch, meta.SeverityWarning, "comments.post_id",
+		`column type "TEXT" differs from the type of parent key posts.id ("INTEGER")`,
+		"Change the type of comments.post_id to INTEGER.")
+
+	autoincrement := finding(meta.RuleNeedlessAutoincrement, meta.SeverityInfo, "events",
+		"table uses AUTOINCREMENT but does not appear to need it",
+		"Remove AUTOINCREMENT unless rowids of deleted rows must never be reused. An INTEGER PRIMARY KEY alone assigns unique rowids.")
+
+	noPrimaryKey := finding(meta.RuleNoPrimaryKey, meta.SeverityWarning, "log",
+		"table has no primary key",
+		"Add a PRIMARY KEY, e.g. an INTEGER PRIMARY KEY column.")
+
+	untyped := finding(meta.RuleUntypedColumn, meta.SeverityInfo, "log.extra",
+		"column has no declared type",
+		"Declare a type (e.g. INTEGER, TEXT, REAL or BLOB) so that the column has the intended affinity.")
+
+	data := []struct {
+		Title    string
+		Options  *meta.LintOptions
+		Findings []meta.Finding
+		Err      error
+	}{
+		{
+			Title: "All Rules",
+			Findings: []meta.Finding{
+				unindexed,
+				mismatch,
+				autoincrement,
+				noPrimaryKey,
+				untyped,
+			},
+		},
+		{
+			Title: "Selected Rules",
+			Options: &meta.LintOptions{
+				Schema: meta.Main,
+				Rules: []meta.LintRule{
+					meta.RuleUntypedColumn,
+					meta.RuleForeignKeyTypeMismatch,
+				},
+			},
+			Findings: []meta.Finding{
+				mismatch,
+				untyped,
+			},
+		},
+		{
+			Title: "Unknown Rule",
+			Options: &meta.LintOptions{
+				Rules: []meta.LintRule{"xxx"},
+			},
+			Err: fmt.Errorf(`could not lint schema main: unknown rule "xxx"`),
+		},
+	}
+
+	for _, test := range data {
+
+		got, err := meta.Lint(db, test.Options)
+		if !equalErrors(test.Err, err) {
+			t.Errorf("%s: Expected error %v, got %v", test.Title, test.Err, err)
+		}
+
+		compareStructSlices(t, test.Title, "finding", "finding(s)", test.Findings, got)
+	}
+}
@@ -0,0 +1,169 @@
+package sqlitemeta
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// A ListOption filters the names returned by the *Names
+// functions (TableNames, ViewNames, TriggerNames, IndexNames
+// and ObjectNames). When multiple options are given, a name is
+// only returned if it satisfies all of them.
+type ListOption func(*listOptions)
+
+type listOptions struct {
+	excludeInternal bool
+	glob            string
+	re              *regexp.Regexp
+	types           []string
+}
+
+// ExcludeInternal excludes objects that are used internally
+// by SQLite, i.e. objects whose names begin with "sqlite_"
+// (such as sqlite_sequence, sqlite_stat1 and the indexes
+// that SQLite creates for UNIQUE constraints), and shadow
+// tables (see ShadowTableNames).
+func ExcludeInternal() ListOption {
+	return func(o *listOptions) {
+		o.excludeInternal = true
+	}
+}
+
+// MatchGlob restricts the results to names that match the
+// given pattern, using the same rules as SQLite's GLOB operator.
+// Matching is case-sensitive, e.g. "user_*".
+func MatchGlob(pattern string) ListOption {
+	return func(o *listOptions) {
+		o.glob = pattern
+	}
+}
+
+// MatchRegexp restricts the results to names that match the
+// given regular expression.
+func MatchRegexp(re *regexp.Regexp) ListOption {
+	return func(o *listOptions) {
+		o.re = re
+	}
+}
+
+// OfType restricts the results to objects of the given types:
+// "table", "view", "trigger" or "index". It is mainly useful
+// with ObjectNames.
+func OfType(types ...string) ListOption {
+	return func(o *listOptions) {
+		o.types = append(o.types, types...)
+	}
+}
+
+func newListOptions(opts []ListOption) *listOptions {
+	o := &listOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// key returns a string that uniquely identifies the options.
+func (o *listOptions) key() string {
+
+	re := ""
+	if o.re != nil {
+		re = o.re.String()
+	}
+
+	return fmt.Sprintf("%t %q %q %q", o.excludeInternal, o.glob, re, o.types)
+}
+
+// ObjectNames returns the names of all of the tables, views,
+// triggers and indexes in the main database, sorted
+// alphabetically. Use the Schema.ObjectNames method to query
+// other databases.
+func ObjectNames(db Querier, opts ...ListOption) ([]string, error) {
+	return noSchema.ObjectNames(db, opts...)
+}
+
+// ObjectNames returns the names of all of the tables, views,
+// triggers and indexes in this Schema, sorted alphabetically.
+func (s *Schema) ObjectNames(db Querier, opts ...ListOption) ([]string, error) {
+	return s.masterTableNames(db, "", opts)
+}
+
+// masterTableNames returns the names of the objects of the
+// given type in this Schema, or all objects if typ is empty.
+func (s *Schema) masterTableNames(db Querier, typ string, opts []ListOption) ([]string, error) {
+
+	o := newListOptions(opts)
+
+	label := "object"
+	if typ != "" {
+		label = typ
+	}
+
+	tableName, err := s.masterTable(db)
+	if err != nil {
+		return nil, fmt.Errorf("could not get %s names: %s", label, err)
+	}
+
+	var where []string
+	var args []interface{}
+
+	if typ != "" {
+		where = append(where, "type = ?")
+		args = append(args, typ)
+	}
+
+	if len(o.types) > 0 {
+		var types []interface{}
+		for _, t := range o.types {
+			types = append(types, sqlower(t))
+		}
+		where = append(where, "type IN ("+placeholdersFor(types)+")")
+		args = append(args, types...)
+	}
+
+	if o.glob != "" {
+		where = append(where, "name GLOB ?")
+		args = append(args, o.glob)
+	}
+
+	q := "SELECT name FROM " + tableName
+	if len(where) > 0 {
+		q += " WHERE " + strings.Join(where, " AND ")
+	}
+	q += " ORDER BY name"
+
+	names, err := queryStrings(db, q, args...)
+	if err != nil {
+		return nil, fmt.Errorf("could not get %s names: %s", label, err)
+	}
+
+	var shadow map[string]bool
+
+	if o.excludeInternal {
+
+		shadowNames, err := s.ShadowTableNames(db)
+		if err != nil {
+			return nil, fmt.Errorf("could not get %s names: %s", label, err)
+		}
+
+		shadow = map[string]bool{}
+		for _, name := range shadowNames {
+			shadow[sqlower(name)] = true
+		}
+	}
+
+	filtered := names[:0]
+
+	for _, name := range names {
+		if o.excludeInternal && (strings.HasPrefix(sqlower(name), "sqlite_") || shadow[sqlower(name)]) {
+			continue
+		}
+		if o.re != nil && !o.re.MatchString(name) {
+			continue
+		}
+		filtered = append(filtered, name)
+	}
+
+	return filtered, nil
+}
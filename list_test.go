@@ -0,0 +1,207 @@
+package sqlitemeta_test
+
+import (
+	"database/sql"
+	"regexp"
+	"testing"
+
+	meta "github.com/deepilla/sqlitemeta"
+)
+
+func TestListOptions(t *testing.T) {
+	testWithDB(t, testListOptions)
+}
+
+func testListOptions(t *testing.T, db *sql.DB) {
+
+	exec(t, db, []string{
+		"DROP TABLE IF EXISTS users",
+		"DROP TABLE IF EXISTS user_roles",
+		"DROP TABLE IF EXISTS places",
+		"DROP VIEW IF EXISTS user_names",
+		"CREATE TABLE users (id INTEGER PRIMARY KEY AUTOINCREMENT, email TEXT UNIQUE)",
+		"CREATE TABLE user_roles (user_id, role)",
+		"CREATE INDEX user_roles_user ON user_roles (user_id)",
+		"CREATE VIEW user_names AS SELECT email FROM users",
+		"CREATE VIRTUAL TABLE places USING rtree(id, minX, maxX)",
+		"INSERT INTO users (email) VALUES ('a@example.com')",
+		"ANALYZE",
+	})
+
+	// ANALYZE creates sqlite_stat1 and, depending on how SQLite
+	// was compiled, sqlite_stat4.
+	rows, err := db.Query("SELECT name FROM sqlite_master WHERE type = 'table' AND name GLOB 'sqlite_stat*' ORDER BY name")
+	if err != nil {
+		t.Fatalf("Could not get stat tables: %s", err)
+	}
+
+	var stats []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			t.Fatalf("Could not get stat tables: %s", err)
+		}
+		stats = append(stats, name)
+	}
+	if err := rows.Close(); err != nil {
+		t.Fatalf("Could not get stat tables: %s", err)
+	}
+
+	allTables := []string{
+		"places",
+		"places_node",
+		"places_parent",
+		"places_rowid",
+		"sqlite_sequence",
+	}
+	allTables = append(allTables, stats...)
+	allTables = append(allTables, "user_roles", "users")
+
+	data := []struct {
+		Title string
+		Func  func(meta.Querier, ...meta.ListOption) ([]string, error)
+		Opts  []meta.ListOption
+		Names []string
+	}{
+		{
+			Title: "All Tables",
+			Func:  meta.TableNames,
+			Names: allTables,
+		},
+		{
+			Title: "User Tables",
+			Func:  meta.TableNames,
+			Opts: []meta.ListOption{
+				meta.ExcludeInternal(),
+			},
+			Names: []string{
+				"places",
+				"user_roles",
+				"users",
+			},
+		},
+		{
+			Title: "User Indexes",
+			Func:  meta.Main.IndexNames,
+			Opts: []meta.ListOption{
+				meta.ExcludeInternal(),
+			},
+			Names: []string{
+				"user_roles_user",
+			},
+		},
+		{
+			Title: "Glob",
+			Func:  meta.TableNames,
+			Opts: []meta.ListOption{
+				meta.MatchGlob("user*"),
+			},
+			Names: []string{
+				"user_roles",
+				"users",
+			},
+		},
+		{
+			Title: "Glob Is Case-Sensitive",
+			Func:  meta.TableNames,
+			Opts: []meta.ListOption{
+				meta.MatchGlob("USER*"),
+			},
+		},
+		{
+			Title: "Regexp",
+			Func:  meta.TableNames,
+			Opts: []meta.ListOption{
+				meta.MatchRegexp(regexp.MustCompile(`_(node|stat1)$`)),
+			},
+			Names: []string{
+				"places_node",
+				"sqlite_stat1",
+			},
+		},
+		{
+			Title: "Combined",
+			Func:  meta.TableNames,
+			Opts: []meta.ListOption{
+				meta.ExcludeInternal(),
+				meta.MatchRegexp(regexp.MustCompile(`^p`)),
+			},
+			Names: []string{
+				"places",
+			},
+		},
+		{
+			Title: "Objects",
+			Func:  meta.ObjectNames,
+			Opts: []meta.ListOption{
+				meta.MatchGlob("user*"),
+			},
+			Names: []string{
+				"user_names",
+				"user_roles",
+				"user_roles_user",
+				"users",
+			},
+		},
+		{
+			Title: "Objects By Type",
+			Func:  meta.Main.ObjectNames,
+			Opts: []meta.ListOption{
+				meta.OfType("VIEW", "index"),
+				meta.ExcludeInternal(),
+			},
+			Names: []string{
+				"user_names",
+				"user_roles_user",
+			},
+		},
+		{
+			Title: "Mismatched Type",
+			Func:  meta.ViewNames,
+			Opts: []meta.ListOption{
+				meta.OfType("table"),
+			},
+		},
+	}
+
+	for _, test := range data {
+
+		got, err := test.Func(db, test.Opts...)
+		if err != nil {
+			t.Fatalf("%s: Names function returned %s", test.Title, err)
+		}
+
+		if !equalStringSlices(got, test.Names) {
+			t.Errorf("%s: Expected names %v, got %v", test.Title, test.Names, got)
+		}
+	}
+
+	cache := meta.NewCache(db)
+
+	for i := 0; i < 2; i++ {
+		for _, test := range data[:4] {
+
+			var got []string
+			var err error
+
+			switch test.Title {
+			case "User Indexes":
+				got, err = cache.IndexNames(test.Opts...)
+			default:
+				got, err = cache.TableNames(test.Opts...)
+			}
+			if err != nil {
+				t.Fatalf("%s (cached): Names method returned %s", test.Title, err)
+			}
+
+			if !equalStringSlices(got, test.Names) {
+				t.Errorf("%s (cached): Expected names %v, got %v", test.Title, test.Names, got)
+			}
+		}
+	}
+
+	_, err = meta.DB("xxxxx").ObjectNames(db)
+	if exp := "could not get object names: unknown database 'xxxxx'"; err == nil || err.Error() != exp {
+		t.Errorf("Expected error %q, got %v", exp, err)
+	}
+}
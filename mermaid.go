@@ -0,0 +1,180 @@
+package sqlitemeta
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// WriteMermaid writes an entity-relationship diagram of the
+// tables in a database to w, as a Mermaid erDiagram.
+//
+// Each table is drawn as an entity listing its columns and
+// declared types, with primary key and foreign key columns
+// marked PK and FK respectively. Each foreign key is drawn as
+// a relationship from the parent table to the child table.
+// A relationship is one-to-one if the child key is covered by
+// a primary key or unique index, and one-to-many otherwise.
+// The parent side is optional if any of the child key columns
+// are nullable.
+//
+// Tables and relationships are written in a fixed order so
+// that the output of an unchanged database is identical from
+// one run to the next.
+//
+// Use opts to restrict the diagram to a particular Schema or
+// to a subset of its tables. A nil opts diagrams every table
+// in the main database.
+func WriteMermaid(w io.Writer, db Querier, opts *DiagramOptions) error {
+
+	d, err := loadDiagram(db, opts)
+	if err != nil {
+		return fmt.Errorf("could not write Mermaid diagram: %s", err)
+	}
+
+	var buf bytes.Buffer
+
+	buf.WriteString("erDiagram\n")
+
+	for _, t := range d.tables {
+
+		fks := map[string]bool{}
+		for _, fk := range t.foreignKeys {
+			for _, name := range fk.ChildKey {
+				fks[sqlower(name)] = true
+			}
+		}
+
+		fmt.Fprintf(&buf, "    %s {\n", mermaidName(t.name))
+
+		// Different column names can map to the same attribute
+		// name, e.g. "tag name" and tag_name.
+		attributes := map[string]int{}
+
+		for _, c := range t.columns {
+
+			var keys []string
+			if c.PrimaryKey > 0 {
+				keys = append(keys, "PK")
+			}
+			if fks[sqlower(c.Name)] {
+				keys = append(keys, "FK")
+			}
+
+			name := uniqueName(mermaidAttribute(c.Name), attributes)
+
+			line := mermaidType(c.Type) + " " + name
+			if len(keys) > 0 {
+				line += " " + strings.Join(keys, ", ")
+			}
+			if name != c.Name {
+				// Include the real column name as a comment.
+				line += " " + mermaidLabel(c.Name)
+			}
+
+			fmt.Fprintf(&buf, "        %s\n", line)
+		}
+
+		buf.WriteString("    }\n")
+	}
+
+	for _, e := range d.edges {
+
+		left := "||"
+		if e.optional {
+			left = "|o"
+		}
+
+		right := "o{"
+		if e.unique {
+			right = "o|"
+		}
+
+		// Identifying relationships (where the child key is
+		// part of the child's primary key) use a solid line.
+		line := ".."
+		if isSubset(e.childKey, primaryKeyColumns(e.child.columns)) {
+			line = "--"
+		}
+
+		fmt.Fprintf(&buf, "    %s %s%s%s %s : %s\n",
+			mermaidName(e.parent.name),
+			left, line, right,
+			mermaidName(e.child.name),
+			mermaidLabel(strings.Join(e.childKey, ", ")))
+	}
+
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+var mermaidBareName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// mermaidName returns s as a Mermaid entity name, quoting it
+// if necessary.
+func mermaidName(s string) string {
+	if mermaidBareName.MatchString(s) {
+		return s
+	}
+	return mermaidLabel(s)
+}
+
+var mermaidAttributeChars = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+
+// mermaidAttribute returns s as a Mermaid attribute name.
+// Attribute names cannot be quoted so any unsupported
+// characters are replaced with underscores.
+func mermaidAttribute(s string) string {
+
+	s = mermaidAttributeChars.ReplaceAllString(s, "_")
+	if s == "" || s[0] >= '0' && s[0] <= '9' {
+		s = "_" + s
+	}
+
+	return s
+}
+
+// mermaidLabel returns s as a quoted Mermaid string.
+func mermaidLabel(s string) string {
+	return `"` + strings.Replace(s, `"`, `'`, -1) + `"`
+}
+
+var mermaidTypeChars = regexp.MustCompile(`[^A-Za-z0-9_()\[\]-]+`)
+
+// mermaidType converts an SQLite declared type into a Mermaid
+// attribute type. Mermaid types must be a single word so any
+// unsupported characters are replaced with underscores. Columns
+// with no declared type are shown as ANY.
+func mermaidType(s string) string {
+
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return "ANY"
+	}
+
+	return mermaidTypeChars.ReplaceAllString(s, "_")
+}
+
+// isSubset reports whether every name in a is also in b,
+// ignoring case.
+func isSubset(a, b []string) bool {
+
+	if len(a) == 0 {
+		return false
+	}
+
+	names := map[string]bool{}
+	for _, name := range b {
+		names[sqlower(name)] = true
+	}
+
+	for _, name := range a {
+		if !names[sqlower(name)] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,65 @@
+package sqlitemeta_test
+
+import (
+	"bytes"
+	"database/sql"
+	"testing"
+
+	meta "github.com/deepilla/sqlitemeta"
+)
+
+func TestWriteMermaid(t *testing.T) {
+	testWithDB(t, testWriteMermaid)
+}
+
+func testWriteMermaid(t *testing.T, db *sql.DB) {
+
+	exec(t, db, diagramSQL)
+	exec(t, db, []string{
+		"DROP TABLE IF EXISTS post_tags",
+		`CREATE TABLE post_tags (
+            post_id INTEGER NOT NULL REFERENCES posts,
+            "tag name" VARCHAR(40) NOT NULL,
+            weight DOUBLE PRECISION,
+            tag_name TEXT,
+            PRIMARY KEY (post_id, "tag name")
+        )`,
+	})
+
+	exp := `erDiagram
+    post_tags {
+        INTEGER post_id PK, FK
+        VARCHAR(40) tag_name PK "tag name"
+        DOUBLE_PRECISION weight
+        TEXT tag_name2 "tag_name"
+    }
+    posts {
+        INTEGER id PK
+        INTEGER user_id FK
+        TEXT title
+    }
+    profiles {
+        INTEGER user_id FK
+        TEXT bio
+    }
+    users {
+        INTEGER id PK
+        TEXT name
+    }
+    posts ||--o{ post_tags : "post_id"
+    users |o..o{ posts : "user_id"
+    users ||..o| profiles : "user_id"
+`
+
+	for i := 0; i < 2; i++ {
+
+		var buf bytes.Buffer
+		if err := meta.WriteMermaid(&buf, db, nil); err != nil {
+			t.Fatalf("WriteMermaid returned error %s", err)
+		}
+
+		if got := buf.String(); got != exp {
+			t.Errorf("Run %d: Expected Mermaid\n%s\ngot\n%s", i+1, exp, got)
+		}
+	}
+}
@@ -0,0 +1,242 @@
+package sqlitemeta
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"runtime"
+	"sort"
+	"sync"
+)
+
+// ParallelSchemaDocs returns the same results as SchemaDocs but
+// fetches table metadata concurrently, using up to n dedicated
+// connections from the given database's connection pool. If n is
+// less than 1, the number of CPUs is used. The order of the
+// results does not depend on the order in which the queries
+// complete.
+//
+// Only the first connection is required. The others are taken
+// from the pool as they become available, so ParallelSchemaDocs
+// makes progress even if n exceeds the pool's maximum number of
+// open connections (see sql.DB.SetMaxOpenConns).
+//
+// The first connection determines which databases are documented.
+// Databases that are backed by a file may be queried on any
+// connection that has the same files attached under the same
+// names. The rest, including the temp database and in-memory
+// databases, are queried on the first connection. To spread the
+// work for an attached database, attach it to every connection
+// (e.g. in a driver connection hook).
+//
+// If the context is cancelled, ParallelSchemaDocs stops as soon
+// as the queries in progress have returned and reports the
+// cancellation as an error.
+func ParallelSchemaDocs(ctx context.Context, db *sql.DB, n int) ([]SchemaDoc, error) {
+
+	if n < 1 {
+		n = runtime.NumCPU()
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not get schema docs: %s", err)
+	}
+	defer conn.Close()
+
+	first := connQuerier{ctx, conn}
+
+	databases, err := databaseList(first)
+	if err != nil {
+		return nil, fmt.Errorf("could not get schema docs: %s", err)
+	}
+
+	sort.Sort(byDatabaseName(databases))
+
+	// Each job fetches the metadata for one table. Jobs for
+	// databases that aren't backed by a file must run on the
+	// first connection.
+	type docJob struct {
+		schema int
+		table  int
+	}
+
+	type schemaJob struct {
+		schema      *Schema
+		objects     []masterObject
+		tables      []TableDoc
+		indexSQL    map[string]string
+		foreignKeys [][]ForeignKey
+	}
+
+	var pinned, jobs []docJob
+	schemas := make([]schemaJob, len(databases))
+
+	for i, d := range databases {
+
+		sj := &schemas[i]
+		sj.schema = DB(d.Name)
+
+		sj.objects, err = sj.schema.masterObjects(first)
+		if err != nil {
+			return nil, fmt.Errorf("could not get schema docs for %s: %s", d.Name, err)
+		}
+
+		sj.tables, sj.indexSQL = docTables(sj.objects)
+		sj.foreignKeys = make([][]ForeignKey, len(sj.tables))
+
+		for j := range sj.tables {
+			if d.File != "" {
+				jobs = append(jobs, docJob{i, j})
+			} else {
+				pinned = append(pinned, docJob{i, j})
+			}
+		}
+	}
+
+	queue := make(chan docJob, len(jobs))
+	for _, job := range jobs {
+		queue <- job
+	}
+	close(queue)
+
+	jobCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Once the first connection has finished, there is no work
+	// left for connections that are still waiting for the pool.
+	connCtx, stopConns := context.WithCancel(jobCtx)
+	defer stopConns()
+
+	var mu sync.Mutex
+	var jobErr error
+
+	fail := func(err error) {
+		mu.Lock()
+		if jobErr == nil {
+			jobErr = err
+			cancel()
+		}
+		mu.Unlock()
+	}
+
+	run := func(db Querier, job docJob) {
+
+		if jobCtx.Err() != nil {
+			return
+		}
+
+		sj := &schemas[job.schema]
+
+		fks, err := sj.schema.tableDoc(db, &sj.tables[job.table], sj.indexSQL)
+		if err != nil {
+			fail(fmt.Errorf("could not get schema docs for %s: %s", sj.schema.name, err))
+			return
+		}
+
+		sj.foreignKeys[job.table] = fks
+	}
+
+	var wg sync.WaitGroup
+
+	for i := 1; i < n && len(jobs) > 0; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			conn, err := db.Conn(connCtx)
+			if err != nil {
+				// Either the work is done or the context
+				// was cancelled, which is reported below.
+				return
+			}
+			defer conn.Close()
+
+			q := connQuerier{jobCtx, conn}
+
+			ok, err := sameDatabases(q, databases)
+			if err != nil {
+				fail(fmt.Errorf("could not get schema docs: %s", err))
+				return
+			}
+			if !ok {
+				return
+			}
+
+			for job := range queue {
+				run(q, job)
+			}
+		}()
+	}
+
+	q := connQuerier{jobCtx, conn}
+	for _, job := range pinned {
+		run(q, job)
+	}
+	for job := range queue {
+		run(q, job)
+	}
+
+	stopConns()
+	wg.Wait()
+
+	if jobErr != nil {
+		return nil, jobErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("could not get schema docs: %s", err)
+	}
+
+	var docs []SchemaDoc
+	for _, sj := range schemas {
+		docs = append(docs, sj.schema.buildDoc(sj.tables, sj.foreignKeys, sj.objects))
+	}
+
+	return docs, nil
+}
+
+// sameDatabases reports whether every file-backed database in
+// the given list is attached to a connection under the same name.
+func sameDatabases(db Querier, databases []databaseRow) (bool, error) {
+
+	rows, err := databaseList(db)
+	if err != nil {
+		return false, err
+	}
+
+	files := map[string]string{}
+	for _, r := range rows {
+		files[sqlower(r.Name)] = r.File
+	}
+
+	for _, d := range databases {
+		if d.File != "" && files[sqlower(d.Name)] != d.File {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// A connQuerier runs queries on a dedicated connection. It uses
+// its own context in place of the one passed to its methods so
+// that queries can be cancelled without threading a context
+// through every function.
+type connQuerier struct {
+	ctx  context.Context
+	conn *sql.Conn
+}
+
+func (q connQuerier) QueryContext(_ context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return q.conn.QueryContext(q.ctx, query, args...)
+}
+
+func (q connQuerier) QueryRowContext(_ context.Context, query string, args ...interface{}) *sql.Row {
+	return q.conn.QueryRowContext(q.ctx, query, args...)
+}
+
+type byDatabaseName []databaseRow
+
+func (d byDatabaseName) Len() int           { return len(d) }
+func (d byDatabaseName) Swap(i, j int)      { d[i], d[j] = d[j], d[i] }
+func (d byDatabaseName) Less(i, j int) bool { return d[i].Name < d[j].Name }
@@ -0,0 +1,123 @@
+package sqlitemeta_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	meta "github.com/deepilla/sqlitemeta"
+)
+
+func TestParallelSchemaDocs(t *testing.T) {
+
+	for _, n := range []int{0, 1, 2, 4} {
+		t.Run(fmt.Sprintf("Connections %d", n), func(t *testing.T) {
+
+			// Use a file database so that the main database is
+			// visible to every connection.
+			db, close, err := fileDB()
+			if err != nil {
+				t.Fatalf("Could not open db: %s", err)
+			}
+			defer close()
+
+			exec(t, db, diagramSQL)
+			exec(t, db, []string{
+				"CREATE INDEX idx_posts_title ON posts(title COLLATE NOCASE DESC, user_id)",
+				"CREATE TABLE tags (id INTEGER PRIMARY KEY, post_id INTEGER REFERENCES posts(id), name TEXT UNIQUE)",
+				"CREATE TABLE comments (id INTEGER PRIMARY KEY, post_id INTEGER REFERENCES posts(id), user_id INTEGER REFERENCES users(id), body TEXT)",
+				"CREATE INDEX idx_comments_user ON comments(user_id)",
+				`CREATE TRIGGER trg_users_delete AFTER DELETE ON users
+                    BEGIN
+                        DELETE FROM posts WHERE user_id = OLD.id;
+                    END`,
+
+				// These databases are only visible to the
+				// connection that created them.
+				"ATTACH DATABASE ':memory:' AS aux",
+				"CREATE TABLE aux.notes (id INTEGER PRIMARY KEY, body TEXT)",
+				"CREATE TEMP TABLE scratch (x, y)",
+			})
+
+			exp, err := meta.SchemaDocs(db)
+			if err != nil {
+				t.Fatalf("SchemaDocs returned error %s", err)
+			}
+
+			got, err := meta.ParallelSchemaDocs(context.Background(), db, n)
+			if err != nil {
+				t.Fatalf("ParallelSchemaDocs returned error %s", err)
+			}
+
+			if len(got) != 3 {
+				t.Fatalf("Expected docs for 3 schemas, got %d", len(got))
+			}
+
+			if !reflect.DeepEqual(exp, got) {
+				t.Errorf("Expected ParallelSchemaDocs to match SchemaDocs\nExp: %+v\nGot: %+v", exp, got)
+			}
+		})
+	}
+}
+
+func TestParallelSchemaDocsPoolLimit(t *testing.T) {
+
+	for _, max := range []int{1, 2} {
+		t.Run(fmt.Sprintf("Max connections %d", max), func(t *testing.T) {
+
+			db, close, err := fileDB()
+			if err != nil {
+				t.Fatalf("Could not open db: %s", err)
+			}
+			defer close()
+
+			exec(t, db, diagramSQL)
+
+			exp, err := meta.SchemaDocs(db)
+			if err != nil {
+				t.Fatalf("SchemaDocs returned error %s", err)
+			}
+
+			db.SetMaxOpenConns(max)
+
+			// Asking for more connections than the pool allows
+			// should not block.
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			got, err := meta.ParallelSchemaDocs(ctx, db, 8)
+			if err != nil {
+				t.Fatalf("ParallelSchemaDocs returned error %s", err)
+			}
+
+			if !reflect.DeepEqual(exp, got) {
+				t.Errorf("Expected ParallelSchemaDocs to match SchemaDocs\nExp: %+v\nGot: %+v", exp, got)
+			}
+		})
+	}
+}
+
+func TestParallelSchemaDocsCancel(t *testing.T) {
+	testWithDB(t, testParallelSchemaDocsCancel)
+}
+
+func testParallelSchemaDocsCancel(t *testing.T, db *sql.DB) {
+
+	exec(t, db, diagramSQL)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	docs, err := meta.ParallelSchemaDocs(ctx, db, 2)
+	if err == nil {
+		t.Fatalf("Expected an error, got %d docs", len(docs))
+	}
+
+	if !strings.Contains(err.Error(), context.Canceled.Error()) {
+		t.Errorf("Expected a cancellation error, got %q", err)
+	}
+}
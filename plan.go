@@ -0,0 +1,468 @@
+package sqlitemeta
+
+import (
+	"fmt"
+	"strings"
+)
+
+// A PlanOp describes the operation performed by a node in a
+// query plan.
+type PlanOp uint
+
+const (
+	// PlanOther denotes a node that does not access a table
+	// directly, e.g. a subquery or compound query.
+	PlanOther PlanOp = iota
+
+	// PlanScan denotes a node that visits every row of a table
+	// or index.
+	PlanScan
+
+	// PlanSearch denotes a node that uses an index or primary
+	// key to visit a subset of a table's rows.
+	PlanSearch
+
+	// PlanTempBTree denotes a node that builds a temporary
+	// b-tree to sort or de-duplicate rows.
+	PlanTempBTree
+)
+
+// String returns the name of a PlanOp, e.g. "SCAN".
+func (op PlanOp) String() string {
+	switch op {
+	case PlanOther:
+		return "OTHER"
+	case PlanScan:
+		return "SCAN"
+	case PlanSearch:
+		return "SEARCH"
+	case PlanTempBTree:
+		return "TEMP B-TREE"
+	default:
+		return fmt.Sprintf("PlanOp(%d)", uint(op))
+	}
+}
+
+// A PlanNode is a single step in a query plan.
+type PlanNode struct {
+	ID       int
+	ParentID int
+	Detail   string // The raw description returned by SQLite.
+	Children []*PlanNode
+
+	Op     PlanOp
+	Schema string // The database containing Table, if the query names it.
+	Table  string // The table scanned or searched, if any.
+	Alias  string // The alias used for Table in the query, if any.
+
+	// For scans and searches of subqueries and common table
+	// expressions, Table is empty and Alias is the name that
+	// the plan uses for the subquery or expression.
+
+	// IndexName is the name of the index used to scan or
+	// search Table. It is empty if no index is used or if the
+	// index is an automatic index created by SQLite for the
+	// duration of the query.
+	IndexName string
+
+	// Index is the metadata for IndexName, or nil if IndexName
+	// is empty or the index could not be found.
+	Index *Index
+
+	// Constraints describes how an index or primary key is
+	// used, e.g. "a=? AND b>?".
+	Constraints string
+
+	// IsCovering is true if the index contains all of the
+	// columns needed by the query, meaning that the table
+	// itself is not accessed.
+	IsCovering bool
+
+	// IsAutomatic is true if SQLite builds a temporary index
+	// for the duration of the query.
+	IsAutomatic bool
+
+	// IsPrimaryKey is true if rows are looked up by rowid or
+	// by the primary key of a WITHOUT ROWID table.
+	IsPrimaryKey bool
+
+	// IsFullScan is true for a SCAN of a table that uses no
+	// index, i.e. one that reads every row of the table.
+	IsFullScan bool
+
+	// TempBTreeFor describes the purpose of a PlanTempBTree
+	// node, e.g. "ORDER BY", "GROUP BY" or "DISTINCT".
+	TempBTreeFor string
+}
+
+// A Plan is the query plan for an SQL statement, as reported
+// by EXPLAIN QUERY PLAN.
+type Plan struct {
+	Nodes []*PlanNode // The top-level nodes of the plan.
+}
+
+// Walk calls fn for every node in the plan, in depth-first
+// order.
+func (p *Plan) Walk(fn func(n *PlanNode)) {
+
+	var walk func(nodes []*PlanNode)
+	walk = func(nodes []*PlanNode) {
+		for _, n := range nodes {
+			fn(n)
+			walk(n.Children)
+		}
+	}
+
+	walk(p.Nodes)
+}
+
+// FullScans returns the nodes in the plan that perform a full
+// table scan.
+func (p *Plan) FullScans() []*PlanNode {
+	return p.filter(func(n *PlanNode) bool {
+		return n.IsFullScan
+	})
+}
+
+// TempBTrees returns the nodes in the plan that build a
+// temporary b-tree, e.g. for ORDER BY or DISTINCT.
+func (p *Plan) TempBTrees() []*PlanNode {
+	return p.filter(func(n *PlanNode) bool {
+		return n.Op == PlanTempBTree
+	})
+}
+
+// IndexNames returns the names of the indexes used by the
+// plan, in the order they appear.
+func (p *Plan) IndexNames() []string {
+
+	var names []string
+	seen := map[string]bool{}
+
+	p.Walk(func(n *PlanNode) {
+		if n.IndexName != "" && !seen[sqlower(n.IndexName)] {
+			seen[sqlower(n.IndexName)] = true
+			names = append(names, n.IndexName)
+		}
+	})
+
+	return names
+}
+
+func (p *Plan) filter(fn func(n *PlanNode) bool) []*PlanNode {
+
+	var nodes []*PlanNode
+
+	p.Walk(func(n *PlanNode) {
+		if fn(n) {
+			nodes = append(nodes, n)
+		}
+	})
+
+	return nodes
+}
+
+// QueryPlan returns the query plan that SQLite would use to
+// execute the given query. Any args are bound to the query's
+// parameters, which can affect the plan (e.g. for partial
+// indexes).
+//
+// Each node in the plan that uses a named index is linked to
+// that index's metadata (see Indexes).
+//
+// The query is not executed. Note that SQLite's EXPLAIN QUERY
+// PLAN output is intended for interactive use and its format
+// may change between SQLite versions.
+func QueryPlan(db Querier, query string, args ...interface{}) (*Plan, error) {
+
+	q := "EXPLAIN QUERY PLAN " + query
+
+	type planRow struct {
+		ID      int
+		Parent  int
+		NotUsed int
+		Detail  string
+	}
+
+	var r planRow
+	var rows []planRow
+
+	err := scanRows(db, q, args, func() {
+		rows = append(rows, r)
+	}, &r.ID, &r.Parent, &r.NotUsed, &r.Detail)
+	if err != nil {
+		return nil, fmt.Errorf("could not get query plan: %s", err)
+	}
+
+	plan := &Plan{}
+	byID := map[int]*PlanNode{}
+	indexes := map[string][]Index{}
+	tokens := tokenize(query)
+
+	// Subqueries and common table expressions are scanned by
+	// name, just like tables, but they are introduced by a
+	// CO-ROUTINE or MATERIALIZE node.
+	derived := map[string]bool{}
+	for _, r := range rows {
+		if name, ok := derivedTableName(r.Detail); ok {
+			derived[sqlower(name)] = true
+		}
+	}
+
+	var databases []databaseRow
+
+	for _, r := range rows {
+
+		n, pk := parsePlanDetail(r.Detail)
+		n.ID = r.ID
+		n.ParentID = r.Parent
+
+		// Tables in other databases are qualified with the
+		// database name, e.g. "aux.posts".
+		if strings.Contains(n.Table, ".") {
+			if databases == nil {
+				if databases, err = databaseList(db); err != nil {
+					return nil, fmt.Errorf("could not get query plan: %s", err)
+				}
+			}
+			n.Schema, n.Table = splitSchema(n.Table, databases)
+		}
+
+		// Since SQLite 3.36, the plan refers to tables by their
+		// alias (if any) so look the alias up in the query.
+		if n.Table != "" && n.Alias == "" && n.Schema == "" {
+			if schema, table, ok := resolveAlias(tokens, n.Table); ok {
+				n.Schema, n.Table, n.Alias = schema, table, n.Table
+			}
+		}
+
+		if n.Table != "" && derived[sqlower(n.Table)] {
+			if n.Alias == "" {
+				n.Alias = n.Table
+			}
+			n.Table = ""
+			n.IndexName = ""
+			n.IsFullScan = false
+			pk = false
+		}
+
+		if n.IndexName != "" || pk {
+			if err := n.lookupIndex(db, indexes); err != nil {
+				return nil, fmt.Errorf("could not get query plan: %s", err)
+			}
+		}
+
+		if parent := byID[r.Parent]; parent != nil {
+			parent.Children = append(parent.Children, n)
+		} else {
+			plan.Nodes = append(plan.Nodes, n)
+		}
+
+		byID[r.ID] = n
+	}
+
+	return plan, nil
+}
+
+// lookupIndex links a PlanNode to the metadata for the index it
+// uses. The cache maps table names to their indexes.
+func (n *PlanNode) lookupIndex(db Querier, cache map[string][]Index) error {
+
+	key := sqlower(n.Schema) + "." + sqlower(n.Table)
+
+	indexes, ok := cache[key]
+	if !ok {
+		var err error
+		if indexes, err = n.schema().Indexes(db, n.Table); err != nil {
+			return err
+		}
+		cache[key] = indexes
+	}
+
+	for i, idx := range indexes {
+
+		// A PRIMARY KEY search on a WITHOUT ROWID table uses
+		// the primary key index.
+		if n.IndexName == "" && idx.Type == IndexTypePrimaryKey ||
+			n.IndexName != "" && sqlower(idx.Name) == sqlower(n.IndexName) {
+
+			n.Index = &indexes[i]
+			n.IndexName = idx.Name
+			break
+		}
+	}
+
+	return nil
+}
+
+// schema returns the Schema containing a PlanNode's table.
+func (n *PlanNode) schema() *Schema {
+	if n.Schema == "" {
+		return noSchema
+	}
+	return DB(n.Schema)
+}
+
+// splitSchema splits a qualified table name from a query plan
+// into its database and table names. Names that don't begin with
+// the name of an attached database are returned unchanged.
+func splitSchema(name string, databases []databaseRow) (string, string) {
+
+	for _, d := range databases {
+		prefix := sqlower(d.Name) + "."
+		if strings.HasPrefix(sqlower(name), prefix) {
+			return name[:len(d.Name)], name[len(prefix):]
+		}
+	}
+
+	return "", name
+}
+
+// parsePlanDetail parses the detail column of EXPLAIN QUERY
+// PLAN output. It understands both the current format, e.g.
+//
+//	SEARCH t USING INDEX idx (a=?)
+//
+// and the format used prior to SQLite 3.36, e.g.
+//
+//	SEARCH TABLE t USING INDEX idx (a=?)
+//
+// The second return value is true if the node uses the primary
+// key index of a WITHOUT ROWID table.
+func parsePlanDetail(detail string) (*PlanNode, bool) {
+
+	n := &PlanNode{
+		Detail: detail,
+	}
+
+	words := strings.Fields(detail)
+	if len(words) == 0 {
+		return n, false
+	}
+
+	switch strings.ToUpper(words[0]) {
+	case "SCAN":
+		n.Op = PlanScan
+	case "SEARCH":
+		n.Op = PlanSearch
+	case "USE":
+		if strings.HasPrefix(strings.ToUpper(detail), "USE TEMP B-TREE FOR ") {
+			n.Op = PlanTempBTree
+			n.TempBTreeFor = detail[len("USE TEMP B-TREE FOR "):]
+		}
+		return n, false
+	default:
+		return n, false
+	}
+
+	rest := words[1:]
+	if len(rest) > 1 && rest[0] == "SUBQUERY" {
+		return n, false
+	}
+	if len(rest) > 1 && rest[0] == "TABLE" {
+		rest = rest[1:]
+	}
+
+	// SCAN CONSTANT ROW doesn't access a table.
+	if len(rest) == 0 || rest[0] == "CONSTANT" || isDigit(rest[0][0]) {
+		return n, false
+	}
+
+	n.Table = rest[0]
+	rest = rest[1:]
+
+	if len(rest) > 1 && rest[0] == "AS" {
+		n.Alias = rest[1]
+		rest = rest[2:]
+	}
+
+	// Anything in parentheses describes the index constraints.
+	if i := strings.Index(detail, " ("); i >= 0 && strings.HasSuffix(detail, ")") {
+		n.Constraints = detail[i+2 : len(detail)-1]
+	}
+
+	rowid := false
+
+	if len(rest) > 0 && rest[0] == "USING" {
+
+		using := rest[1:]
+		for len(using) > 0 && using[0] != "INDEX" && using[0] != "KEY" {
+			switch using[0] {
+			case "COVERING":
+				n.IsCovering = true
+			case "AUTOMATIC":
+				n.IsAutomatic = true
+			case "INTEGER", "ROWID":
+				rowid = true
+			case "PRIMARY":
+				n.IsPrimaryKey = true
+			}
+			using = using[1:]
+		}
+
+		if len(using) > 1 && using[0] == "INDEX" && !n.IsAutomatic && !strings.HasPrefix(using[1], "(") {
+			n.IndexName = using[1]
+		}
+	}
+
+	n.IsFullScan = n.Op == PlanScan && n.IndexName == "" && !n.IsAutomatic && !strings.Contains(detail, "VIRTUAL TABLE")
+
+	return n, n.IsPrimaryKey && !rowid
+}
+
+// derivedTableName returns the name of the subquery or common
+// table expression introduced by a plan node, e.g. "s" for
+// "CO-ROUTINE s" or "c" for "MATERIALIZE c".
+func derivedTableName(detail string) (string, bool) {
+
+	words := strings.Fields(detail)
+	if len(words) != 2 {
+		return "", false
+	}
+
+	switch strings.ToUpper(words[0]) {
+	case "CO-ROUTINE", "MATERIALIZE":
+		return words[1], true
+	default:
+		return "", false
+	}
+}
+
+// resolveAlias returns the database name (if given) and the name
+// of the table with the given alias in a tokenized query. It recognises table references of
+// the form "table alias" or "table AS alias" following FROM,
+// JOIN, UPDATE, INTO, a comma or a schema name.
+func resolveAlias(tokens []token, alias string) (string, string, bool) {
+
+	isTableStart := func(i int) bool {
+		if i < 0 {
+			return false
+		}
+		t := tokens[i]
+		return t.isWord("FROM") || t.isWord("JOIN") || t.isWord("UPDATE") || t.isWord("INTO") || t.isPunct(",") || t.isPunct(".")
+	}
+
+	for i := 2; i < len(tokens); i++ {
+
+		if !tokens[i].isName() || sqlower(tokens[i].value()) != sqlower(alias) {
+			continue
+		}
+
+		j := i - 1
+		if tokens[j].isWord("AS") {
+			j--
+		}
+
+		if j >= 0 && tokens[j].isName() && !tokens[j].isWord("AS") && !isTableStart(j) && isTableStart(j-1) {
+			if table := tokens[j].value(); sqlower(table) != sqlower(alias) {
+				schema := ""
+				if j >= 2 && tokens[j-1].isPunct(".") && tokens[j-2].isName() {
+					schema = tokens[j-2].value()
+				}
+				return schema, table, true
+			}
+		}
+	}
+
+	return "", "", false
+}
@@ -0,0 +1,230 @@
+package sqlitemeta_test
+
+import (
+	"database/sql"
+	"testing"
+
+	meta "github.com/deepilla/sqlitemeta"
+)
+
+func TestQueryPlan(t *testing.T) {
+	testWithDB(t, testQueryPlan)
+}
+
+func testQueryPlan(t *testing.T, db *sql.DB) {
+
+	exec(t, db, []string{
+		"DROP TABLE IF EXISTS orders",
+		"DROP TABLE IF EXISTS customers",
+		"DROP TABLE IF EXISTS kv",
+
+		`CREATE TABLE customers (
+            id INTEGER PRIMARY KEY,
+            name TEXT,
+            city TEXT
+        )`,
+		"CREATE INDEX idx_customers_city ON customers(city)",
+		`CREATE TABLE orders (
+            id INTEGER PRIMARY KEY,
+            customer_id INTEGER,
+            total REAL
+        )`,
+		"CREATE INDEX idx_orders_customer ON orders(customer_id, total)",
+		`CREATE TABLE kv (
+            k TEXT PRIMARY KEY,
+            v
+        ) WITHOUT ROWID`,
+	})
+
+	type node struct {
+		Op           meta.PlanOp
+		Table        string
+		Alias        string
+		IndexName    string
+		IsCovering   bool
+		IsPrimaryKey bool
+		IsFullScan   bool
+		TempBTreeFor string
+	}
+
+	data := []struct {
+		Title string
+		Query string
+		Args  []interface{}
+		Nodes []node
+	}{
+		{
+			Title: "Full Scan",
+			Query: "SELECT * FROM customers WHERE name = 'x'",
+			Nodes: []node{
+				{
+					Op:         meta.PlanScan,
+					Table:      "customers",
+					IsFullScan: true,
+				},
+			},
+		},
+		{
+			Title: "Index Search",
+			Query: "SELECT * FROM customers c WHERE city = ?",
+			Args:  []interface{}{"Paris"},
+			Nodes: []node{
+				{
+					Op:        meta.PlanSearch,
+					Table:     "customers",
+					Alias:     "c",
+					IndexName: "idx_customers_city",
+				},
+			},
+		},
+		{
+			Title: "Covering Index",
+			Query: "SELECT total FROM orders WHERE customer_id = 1",
+			Nodes: []node{
+				{
+					Op:         meta.PlanSearch,
+					Table:      "orders",
+					IndexName:  "idx_orders_customer",
+					IsCovering: true,
+				},
+			},
+		},
+		{
+			Title: "Rowid",
+			Query: "SELECT * FROM orders WHERE id = 1",
+			Nodes: []node{
+				{
+					Op:           meta.PlanSearch,
+					Table:        "orders",
+					IsPrimaryKey: true,
+				},
+			},
+		},
+		{
+			Title: "Without Rowid",
+			Query: "SELECT * FROM kv WHERE k = 'x'",
+			Nodes: []node{
+				{
+					Op:           meta.PlanSearch,
+					Table:        "kv",
+					IndexName:    "sqlite_autoindex_kv_1",
+					IsPrimaryKey: true,
+				},
+			},
+		},
+		{
+			Title: "Temp B-Tree",
+			Query: "SELECT DISTINCT name FROM customers ORDER BY city",
+			Nodes: []node{
+				{
+					Op:        meta.PlanScan,
+					Table:     "customers",
+					IndexName: "idx_customers_city",
+				},
+				{
+					Op:           meta.PlanTempBTree,
+					TempBTreeFor: "DISTINCT",
+				},
+			},
+		},
+		{
+			Title: "Subquery",
+			Query: "SELECT * FROM (SELECT city, count(*) AS n FROM customers GROUP BY city) s WHERE n > 1",
+			Nodes: []node{
+				{
+					Op: meta.PlanOther,
+				},
+				{
+					Op:         meta.PlanScan,
+					Table:      "customers",
+					IndexName:  "idx_customers_city",
+					IsCovering: true,
+				},
+				{
+					Op:    meta.PlanScan,
+					Alias: "s",
+				},
+			},
+		},
+		{
+			Title: "Common Table Expression",
+			Query: "WITH c AS MATERIALIZED (SELECT name FROM customers) SELECT * FROM c AS x",
+			Nodes: []node{
+				{
+					Op: meta.PlanOther,
+				},
+				{
+					Op:         meta.PlanScan,
+					Table:      "customers",
+					IsFullScan: true,
+				},
+				{
+					Op:    meta.PlanScan,
+					Alias: "x",
+				},
+			},
+		},
+	}
+
+	for _, test := range data {
+
+		plan, err := meta.QueryPlan(db, test.Query, test.Args...)
+		if err != nil {
+			t.Fatalf("%s: QueryPlan returned error %s", test.Title, err)
+		}
+
+		var got []node
+		plan.Walk(func(n *meta.PlanNode) {
+
+			got = append(got, node{
+				Op:           n.Op,
+				Table:        n.Table,
+				Alias:        n.Alias,
+				IndexName:    n.IndexName,
+				IsCovering:   n.IsCovering,
+				IsPrimaryKey: n.IsPrimaryKey,
+				IsFullScan:   n.IsFullScan,
+				TempBTreeFor: n.TempBTreeFor,
+			})
+
+			if n.IndexName != "" && (n.Index == nil || n.Index.Name != n.IndexName) {
+				t.Errorf("%s: Expected node %q to have metadata for index %s, got %v", test.Title, n.Detail, n.IndexName, n.Index)
+			}
+		})
+
+		compareStructSlices(t, test.Title, "node", "node(s)", test.Nodes, got)
+	}
+
+	plan, err := meta.QueryPlan(db, "SELECT * FROM customers c JOIN orders o ON o.total = c.id ORDER BY o.total")
+	if err != nil {
+		t.Fatalf("QueryPlan returned error %s", err)
+	}
+
+	if n := len(plan.FullScans()); n != 1 {
+		t.Errorf("Expected 1 full scan, got %d", n)
+	}
+
+	if n := len(plan.TempBTrees()); n != 1 {
+		t.Errorf("Expected 1 temp b-tree, got %d", n)
+	}
+
+	// Scans of subqueries and common table expressions are
+	// not full table scans.
+	plan, err = meta.QueryPlan(db, "WITH c AS MATERIALIZED (SELECT id FROM orders) SELECT * FROM c, (SELECT DISTINCT city FROM customers) s")
+	if err != nil {
+		t.Fatalf("QueryPlan returned error %s", err)
+	}
+
+	var scans []string
+	for _, n := range plan.FullScans() {
+		scans = append(scans, n.Table)
+	}
+
+	if exp := []string{"orders"}; !equalStringSlices(exp, scans) {
+		t.Errorf("Expected full scans of %v, got %v", exp, scans)
+	}
+
+	if _, err := meta.QueryPlan(db, "SELECT * FROM xxxxx"); err == nil {
+		t.Errorf("Expected QueryPlan to return an error for an invalid query")
+	}
+}
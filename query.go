@@ -1,27 +1,44 @@
 package sqlitemeta
 
 import (
+	"context"
 	"database/sql"
-	"fmt"
-	"reflect"
 )
 
-func queryRows(dest interface{}, db *sql.DB, q string, args ...interface{}) error {
-
-	pv := reflect.ValueOf(dest)
-	mustBePtr(pv, reflect.Slice)
-
-	v := pv.Elem()
-	t := v.Type().Elem()
+// A Querier runs queries. It is implemented by *sql.DB, *sql.Conn
+// and *sql.Tx.
+//
+// Most of the functions and methods in this package accept a
+// Querier so that they can be used with a single connection
+// (e.g. one that a database has been attached to) or within a
+// transaction.
+type Querier interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
 
-	addrsOf := getStructFields
-	if t.Kind() != reflect.Struct {
-		addrsOf = func(v reflect.Value) []interface{} {
-			return []interface{}{v.Interface()}
-		}
-	}
+// A TxBeginner runs queries and begins transactions. It is
+// implemented by *sql.DB and *sql.Conn.
+//
+// Functions that modify a database accept a TxBeginner so that
+// they can be used with a single connection (e.g. one that a
+// database has been attached to).
+type TxBeginner interface {
+	Querier
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
 
-	rows, err := db.Query(q, args...)
+// scanRows runs a query and scans each row of the results into
+// dest, calling fn after each row has been scanned. The same
+// destinations are used for every row so fn must copy any values
+// that it needs to keep (typically by appending a struct whose
+// fields are the destinations to a slice).
+//
+// Reusing the destinations avoids allocating a new set of scan
+// arguments for every row.
+func scanRows(db Querier, q string, args []interface{}, fn func(), dest ...interface{}) error {
+
+	rows, err := db.QueryContext(context.Background(), q, args...)
 	if err != nil {
 		return err
 	}
@@ -29,51 +46,27 @@ func queryRows(dest interface{}, db *sql.DB, q string, args ...interface{}) erro
 
 	for rows.Next() {
 
-		p := reflect.New(t)
-
-		if err = rows.Scan(addrsOf(p)...); err != nil {
+		if err = rows.Scan(dest...); err != nil {
 			return err
 		}
 
-		v.Set(reflect.Append(v, p.Elem()))
+		fn()
 	}
 
 	return rows.Err()
 }
 
-func queryStrings(db *sql.DB, q string, args ...interface{}) ([]string, error) {
+func queryStrings(db Querier, q string, args ...interface{}) ([]string, error) {
 
+	var value string
 	var values []string
 
-	err := queryRows(&values, db, q, args...)
+	err := scanRows(db, q, args, func() {
+		values = append(values, value)
+	}, &value)
 	if err != nil {
 		return nil, err
 	}
 
 	return values, nil
 }
-
-func getStructFields(pv reflect.Value) []interface{} {
-
-	mustBePtr(pv, reflect.Struct)
-
-	var fields []interface{}
-
-	v := pv.Elem()
-	for i := 0; i < v.NumField(); i++ {
-		fields = append(fields, v.Field(i).Addr().Interface())
-	}
-
-	return fields
-}
-
-func mustBe(v reflect.Value, k reflect.Kind) {
-	if v.Kind() != k {
-		panic(fmt.Sprintf("expected kind %v, got %v", k, v.Kind()))
-	}
-}
-
-func mustBePtr(v reflect.Value, k reflect.Kind) {
-	mustBe(v, reflect.Ptr)
-	mustBe(v.Elem(), k)
-}
@@ -0,0 +1,570 @@
+package sqlitemeta
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// A Query is an SQL statement and the arguments bound to its
+// parameters.
+type Query struct {
+	SQL  string
+	Args []interface{}
+}
+
+// An IndexRecommendation is a proposed index that improves the
+// query plan for one or more queries in a workload.
+type IndexRecommendation struct {
+	Table   string
+	Columns []string
+	SQL     string // The CREATE INDEX statement for the index.
+
+	// Queries lists the positions in the workload of the
+	// queries whose plans are improved by the index.
+	Queries []int
+}
+
+// RecommendIndexes suggests indexes that would speed up the
+// given queries, sorted by the number of queries improved.
+//
+// Candidate indexes are derived from the tables that SQLite
+// scans in full (or for which it builds an automatic index)
+// and from the columns of those tables that are compared
+// with = or IN, or with a range operator, in the WHERE or ON
+// clauses of a query. Equality columns are placed first,
+// followed by at most one range column.
+//
+// Each candidate is verified against an in-memory copy of the
+// main database's schema (including any sqlite_stat1 data). A
+// candidate is recommended only if it is used to search a table
+// by, and reduces the number of full scans and automatic indexes
+// in, the plan for at least one query. The database itself is not
+// modified and the queries are not executed.
+//
+// Recommended indexes are named after their table and columns,
+// with a numeric suffix if the name is already taken.
+//
+// The queries may only refer to objects in the main database.
+func RecommendIndexes(db Querier, queries []Query) ([]IndexRecommendation, error) {
+
+	recs, err := recommendIndexes(db, queries)
+	if err != nil {
+		return nil, fmt.Errorf("could not recommend indexes: %s", err)
+	}
+
+	return recs, nil
+}
+
+func recommendIndexes(db Querier, queries []Query) ([]IndexRecommendation, error) {
+
+	clone, err := cloneSchema(db)
+	if err != nil {
+		return nil, err
+	}
+	defer clone.Close()
+
+	costs := make([]int, len(queries))
+	columns := map[string][]string{}
+
+	var candidates []*IndexRecommendation
+
+	for i, q := range queries {
+
+		plan, err := QueryPlan(clone, q.SQL, q.Args...)
+		if err != nil {
+			return nil, err
+		}
+
+		costs[i] = planCost(plan)
+		tokens := tokenize(q.SQL)
+
+		for _, n := range planScans(plan) {
+
+			key := sqlower(n.Table)
+			if _, ok := columns[key]; !ok {
+				cols, err := Columns(clone, n.Table)
+				if err != nil {
+					return nil, err
+				}
+				var names []string
+				for _, c := range cols {
+					names = append(names, c.Name)
+				}
+				columns[key] = names
+			}
+
+			cols := filterColumns(tokens, n.Table, n.Alias, columns[key])
+			if len(cols) == 0 {
+				continue
+			}
+
+			rec := &IndexRecommendation{
+				Table:   n.Table,
+				Columns: cols,
+			}
+
+			if hasRecommendation(candidates, rec) {
+				continue
+			}
+
+			name, err := unusedName(clone, indexName(n.Table, cols))
+			if err != nil {
+				return nil, err
+			}
+
+			rec.SQL = createIndexStmt(quoteIdent(name), n.Table, cols) + ";"
+			candidates = append(candidates, rec)
+		}
+	}
+
+	for _, rec := range candidates {
+		if err := rec.verify(clone, queries, costs); err != nil {
+			return nil, err
+		}
+	}
+
+	var recs []IndexRecommendation
+
+	for _, rec := range candidates {
+		if len(rec.Queries) > 0 && !isSupersededBy(rec, candidates) {
+			recs = append(recs, *rec)
+		}
+	}
+
+	sort.Stable(byQueriesImproved(recs))
+
+	return recs, nil
+}
+
+// verify creates a candidate index in the cloned database and
+// records the queries whose plans it improves. The index is
+// created under a temporary name that doesn't clash with any
+// existing object.
+func (rec *IndexRecommendation) verify(clone *sql.DB, queries []Query, costs []int) error {
+
+	name, err := unusedName(clone, "sqlitemeta_candidate")
+	if err != nil {
+		return err
+	}
+
+	if _, err := clone.Exec(createIndexStmt(quoteIdent(name), rec.Table, rec.Columns)); err != nil {
+		return err
+	}
+
+	for i, q := range queries {
+
+		plan, err := QueryPlan(clone, q.SQL, q.Args...)
+		if err != nil {
+			return err
+		}
+
+		if planCost(plan) >= costs[i] {
+			continue
+		}
+
+		// Scanning the new index in place of the table (e.g.
+		// for a COUNT) is not considered an improvement.
+		searches := plan.filter(func(n *PlanNode) bool {
+			return n.Op == PlanSearch && sqlower(n.IndexName) == sqlower(name)
+		})
+
+		if len(searches) > 0 {
+			rec.Queries = append(rec.Queries, i)
+		}
+	}
+
+	_, err = clone.Exec("DROP INDEX " + quoteIdent(name))
+	return err
+}
+
+// unusedName returns the given name, with a numeric suffix if
+// necessary, such that no object in the database has that name.
+func unusedName(db *sql.DB, name string) (string, error) {
+
+	candidate := name
+
+	for i := 2; ; i++ {
+
+		var count int
+		if err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE name = ? COLLATE NOCASE", candidate).Scan(&count); err != nil {
+			return "", err
+		}
+		if count == 0 {
+			return candidate, nil
+		}
+
+		candidate = name + "_" + strconv.Itoa(i)
+	}
+}
+
+type byQueriesImproved []IndexRecommendation
+
+func (r byQueriesImproved) Len() int           { return len(r) }
+func (r byQueriesImproved) Less(i, j int) bool { return len(r[i].Queries) > len(r[j].Queries) }
+func (r byQueriesImproved) Swap(i, j int)      { r[i], r[j] = r[j], r[i] }
+
+// planScans returns the nodes in a plan that scan a table in
+// full or build an automatic index on it.
+func planScans(p *Plan) []*PlanNode {
+	return p.filter(func(n *PlanNode) bool {
+		return n.Table != "" && (n.IsFullScan || n.IsAutomatic)
+	})
+}
+
+// planCost returns a rough measure of the cost of a plan: the
+// number of full scans and automatic indexes it uses.
+func planCost(p *Plan) int {
+	return len(planScans(p))
+}
+
+func hasRecommendation(recs []*IndexRecommendation, rec *IndexRecommendation) bool {
+	for _, r := range recs {
+		if sqlower(r.Table) == sqlower(rec.Table) && equalNames(r.Columns, rec.Columns) {
+			return true
+		}
+	}
+	return false
+}
+
+// isSupersededBy reports whether rec is made unnecessary by a
+// longer candidate on the same table that begins with the same
+// columns and improves the same queries.
+func isSupersededBy(rec *IndexRecommendation, candidates []*IndexRecommendation) bool {
+
+	for _, other := range candidates {
+
+		if other == rec || sqlower(other.Table) != sqlower(rec.Table) ||
+			len(other.Columns) <= len(rec.Columns) ||
+			!equalNames(other.Columns[:len(rec.Columns)], rec.Columns) {
+			continue
+		}
+
+		if isSubsetInts(rec.Queries, other.Queries) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isSubsetInts(a, b []int) bool {
+
+	m := map[int]bool{}
+	for _, n := range b {
+		m[n] = true
+	}
+
+	for _, n := range a {
+		if !m[n] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// equalNames reports whether a and b contain the same names in
+// the same order, ignoring case.
+func equalNames(a, b []string) bool {
+
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if sqlower(a[i]) != sqlower(b[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// filterColumns returns the columns of a table that are
+// compared with other values in the WHERE and ON clauses of a
+// tokenized query. Columns compared for equality come first,
+// in the order they appear, followed by the first column that
+// is compared with a range operator.
+func filterColumns(tokens []token, table, alias string, columns []string) []string {
+
+	isColumn := map[string]string{}
+	for _, c := range columns {
+		isColumn[sqlower(c)] = c
+	}
+
+	var equality []string
+	var ranges []string
+
+	inFilter := false
+
+	for i, t := range tokens {
+
+		switch {
+		case t.isWord("WHERE"), t.isWord("ON"):
+			inFilter = true
+			continue
+		case t.isWord("SELECT"), t.isWord("FROM"), t.isWord("GROUP"), t.isWord("ORDER"),
+			t.isWord("HAVING"), t.isWord("LIMIT"), t.isWord("SET"), t.isWord("UNION"),
+			t.isWord("RETURNING"):
+			inFilter = false
+			continue
+		}
+
+		if !inFilter || !t.isName() || t.kind == tokenString {
+			continue
+		}
+
+		name, ok := isColumn[sqlower(t.value())]
+		if !ok || i+1 < len(tokens) && (tokens[i+1].isPunct(".") || tokens[i+1].isPunct("(")) {
+			continue
+		}
+
+		start := i
+		if i > 1 && tokens[i-1].isPunct(".") {
+			qualifier := sqlower(tokens[i-2].value())
+			if qualifier != sqlower(table) && qualifier != sqlower(alias) {
+				continue
+			}
+			start = i - 2
+		}
+
+		var before, after token
+		if start > 0 {
+			before = tokens[start-1]
+		}
+		if i+1 < len(tokens) {
+			after = tokens[i+1]
+		}
+
+		switch {
+		case isEqualityOp(after) || isEqualityOp(before) && !before.isWord("IN"):
+			equality = appendName(equality, name)
+		case isRangeOp(after) || isRangeOp(before) && !before.isWord("BETWEEN"):
+			ranges = appendName(ranges, name)
+		}
+	}
+
+	for _, name := range ranges {
+		if !containsName(equality, name) {
+			return append(equality, name)
+		}
+	}
+
+	return equality
+}
+
+func isEqualityOp(t token) bool {
+	return t.isPunct("=") || t.isPunct("==") || t.isWord("IN") || t.isWord("IS")
+}
+
+func isRangeOp(t token) bool {
+	return t.isPunct("<") || t.isPunct(">") || t.isPunct("<=") || t.isPunct(">=") || t.isWord("BETWEEN")
+}
+
+func appendName(names []string, name string) []string {
+	if containsName(names, name) {
+		return names
+	}
+	return append(names, name)
+}
+
+func containsName(names []string, name string) bool {
+	for _, s := range names {
+		if sqlower(s) == sqlower(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// indexName returns a conventional name for an index on the
+// given columns, e.g. "idx_posts_user_id".
+func indexName(table string, columns []string) string {
+	return "idx_" + table + "_" + strings.Join(columns, "_")
+}
+
+// createIndexSQL returns a CREATE INDEX statement for an index
+// on the given columns of a table in the named database.
+func createIndexSQL(schema, table string, columns []string) string {
+	return createIndexStmt(quoteIdent(schema)+"."+quoteIdent(indexName(table, columns)), table, columns) + ";"
+}
+
+// createIndexStmt returns a CREATE INDEX statement for the named
+// index. The name must already be quoted.
+func createIndexStmt(name, table string, columns []string) string {
+
+	var quoted []string
+	for _, col := range columns {
+		quoted = append(quoted, quoteIdent(col))
+	}
+
+	return fmt.Sprintf("CREATE INDEX %s ON %s (%s)", name, quoteIdent(table), strings.Join(quoted, ", "))
+}
+
+// cloneSchema creates an empty in-memory database with the same
+// schema as the main database.
+// Statistics from sqlite_stat1 are also copied so that the
+// query planner makes the same choices in both databases.
+func cloneSchema(db Querier) (*sql.DB, error) {
+
+	clone, err := openMemoryDB(db)
+	if err != nil {
+		return nil, err
+	}
+
+	// Each connection to ":memory:" opens a separate database
+	// so restrict the pool to a single connection.
+	clone.SetMaxOpenConns(1)
+
+	err = copySchema(clone, db)
+	if err != nil {
+		clone.Close()
+		return nil, err
+	}
+
+	return clone, nil
+}
+
+func copySchema(clone *sql.DB, db Querier) error {
+
+	// Order by rowid so that objects are created after the
+	// objects they depend on.
+	var obj masterObject
+	var objects []masterObject
+
+	err := scanRows(db, "SELECT type, name, tbl_name, sql FROM sqlite_master WHERE sql IS NOT NULL ORDER BY rowid", nil, func() {
+		objects = append(objects, obj)
+	}, obj.dest()...)
+	if err != nil {
+		return err
+	}
+
+	hasStats := false
+
+	for _, obj := range objects {
+
+		if strings.HasPrefix(sqlower(obj.Name), "sqlite_") {
+			hasStats = hasStats || sqlower(obj.Name) == "sqlite_stat1"
+			continue
+		}
+
+		// Virtual tables create their own shadow tables.
+		if obj.Type == "table" {
+			var count int
+			if err := clone.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE name = ?", obj.Name).Scan(&count); err != nil {
+				return err
+			}
+			if count > 0 {
+				continue
+			}
+		}
+
+		if _, err := clone.Exec(obj.SQL.String); err != nil {
+			return fmt.Errorf("could not copy %s %s: %s", obj.Type, obj.Name, err)
+		}
+	}
+
+	if !hasStats {
+		return nil
+	}
+
+	var st stat1Row
+	var stats []stat1Row
+
+	err = scanRows(db, "SELECT tbl, idx, stat FROM sqlite_stat1", nil, func() {
+		stats = append(stats, st)
+	}, &st.Table, &st.Index, &st.Stat)
+	if err != nil {
+		return err
+	}
+
+	// Running ANALYZE on the empty database creates the stats
+	// table. Running it on sqlite_master after the stats have
+	// been copied causes SQLite to reload them.
+	if _, err := clone.Exec("ANALYZE"); err != nil {
+		return err
+	}
+	if _, err := clone.Exec("DELETE FROM sqlite_stat1"); err != nil {
+		return err
+	}
+
+	for _, s := range stats {
+		if _, err := clone.Exec("INSERT INTO sqlite_stat1 (tbl, idx, stat) VALUES (?, ?, ?)", s.Table, s.Index, s.Stat); err != nil {
+			return err
+		}
+	}
+
+	_, err = clone.Exec("ANALYZE sqlite_master")
+	return err
+}
+
+// openMemoryDB opens an in-memory database using the same driver
+// as db. The driver is looked up among the registered drivers
+// because the sql package doesn't expose the name that db was
+// opened with.
+//
+// Connections and transactions don't expose their driver at all,
+// so for those, the first registered driver that reports the
+// same SQLite version is used.
+func openMemoryDB(db Querier) (*sql.DB, error) {
+
+	if d, ok := db.(interface {
+		Driver() driver.Driver
+	}); ok {
+		return openDriverDB(d.Driver())
+	}
+
+	var version string
+	if err := db.QueryRowContext(context.Background(), "SELECT sqlite_version()").Scan(&version); err != nil {
+		return nil, err
+	}
+
+	for _, name := range sql.Drivers() {
+
+		clone, err := sql.Open(name, ":memory:")
+		if err != nil {
+			continue
+		}
+
+		var v string
+		if err := clone.QueryRow("SELECT sqlite_version()").Scan(&v); err == nil && v == version {
+			return clone, nil
+		}
+
+		clone.Close()
+	}
+
+	return nil, fmt.Errorf("no registered driver for SQLite %s", version)
+}
+
+// openDriverDB opens an in-memory database using the given
+// driver.
+func openDriverDB(drv driver.Driver) (*sql.DB, error) {
+
+	// Comparing drivers of an incomparable type would panic.
+	if !reflect.TypeOf(drv).Comparable() {
+		return nil, fmt.Errorf("unsupported driver %T", drv)
+	}
+
+	for _, name := range sql.Drivers() {
+
+		clone, err := sql.Open(name, ":memory:")
+		if err != nil {
+			continue
+		}
+
+		if clone.Driver() == drv {
+			return clone, nil
+		}
+
+		clone.Close()
+	}
+
+	return nil, fmt.Errorf("driver %T is not registered", drv)
+}
@@ -0,0 +1,139 @@
+package sqlitemeta_test
+
+import (
+	"database/sql"
+	"testing"
+
+	meta "github.com/deepilla/sqlitemeta"
+)
+
+func TestRecommendIndexes(t *testing.T) {
+	testWithDB(t, testRecommendIndexes)
+}
+
+func testRecommendIndexes(t *testing.T, db *sql.DB) {
+
+	exec(t, db, []string{
+		"DROP TABLE IF EXISTS orders",
+		"DROP TABLE IF EXISTS customers",
+
+		`CREATE TABLE customers (
+            id INTEGER PRIMARY KEY,
+            email TEXT,
+            country TEXT
+        )`,
+		`CREATE TABLE orders (
+            id INTEGER PRIMARY KEY,
+            customer_id INTEGER REFERENCES customers(id),
+            status TEXT,
+            created_at TEXT
+        )`,
+		"CREATE INDEX idx_orders_status ON orders(status)",
+		"INSERT INTO orders (status) VALUES ('new'), ('paid'), ('shipped')",
+		"ANALYZE",
+	})
+
+	queries := []meta.Query{
+		{
+			SQL:  "SELECT * FROM customers WHERE email = ?",
+			Args: []interface{}{"a@example.com"},
+		},
+		{
+			SQL: "SELECT * FROM orders WHERE id = 1",
+		},
+		{
+			SQL:  "SELECT * FROM orders o WHERE o.customer_id = ? AND o.created_at > ?",
+			Args: []interface{}{1, "2017-01-01"},
+		},
+		{
+			SQL: "SELECT COUNT(*) FROM customers",
+		},
+		{
+			SQL:  "SELECT * FROM orders WHERE status = ?",
+			Args: []interface{}{"new"},
+		},
+		{
+			SQL: "SELECT * FROM orders WHERE customer_id IN (1, 2, 3) ORDER BY created_at",
+		},
+	}
+
+	exp := []meta.IndexRecommendation{
+		{
+			Table:   "orders",
+			Columns: []string{"customer_id", "created_at"},
+			SQL:     `CREATE INDEX "idx_orders_customer_id_created_at" ON "orders" ("customer_id", "created_at");`,
+			Queries: []int{2, 5},
+		},
+		{
+			Table:   "customers",
+			Columns: []string{"email"},
+			SQL:     `CREATE INDEX "idx_customers_email" ON "customers" ("email");`,
+			Queries: []int{0},
+		},
+	}
+
+	got, err := meta.RecommendIndexes(db, queries)
+	if err != nil {
+		t.Fatalf("RecommendIndexes returned error %s", err)
+	}
+
+	compareStructSlices(t, "RecommendIndexes", "recommendation", "recommendations", exp, got)
+
+	// The database itself should be unchanged.
+	names, err := meta.IndexNames(db)
+	if err != nil {
+		t.Fatalf("IndexNames returned error %s", err)
+	}
+
+	if exp := []string{"idx_orders_status"}; !equalStringSlices(exp, names) {
+		t.Errorf("Expected indexes %q, got %q", exp, names)
+	}
+
+	_, err = meta.RecommendIndexes(db, []meta.Query{{SQL: "SELECT * FROM xxxxx"}})
+	if err == nil {
+		t.Errorf("Expected RecommendIndexes to return an error for an invalid query")
+	}
+}
+
+func TestRecommendIndexesNameCollision(t *testing.T) {
+	testWithDB(t, testRecommendIndexesNameCollision)
+}
+
+func testRecommendIndexesNameCollision(t *testing.T, db *sql.DB) {
+
+	// Existing objects use both the conventional name for the
+	// recommended index and the temporary name for candidates.
+	exec(t, db, []string{
+		"DROP TABLE IF EXISTS customers",
+		"DROP TABLE IF EXISTS sqlitemeta_candidate",
+		"CREATE TABLE customers (id INTEGER PRIMARY KEY, email TEXT, country TEXT)",
+		"CREATE INDEX idx_customers_email ON customers(country)",
+		"CREATE TABLE sqlitemeta_candidate (x)",
+	})
+
+	queries := []meta.Query{
+		{
+			SQL:  "SELECT * FROM customers WHERE email = ?",
+			Args: []interface{}{"a@example.com"},
+		},
+	}
+
+	exp := []meta.IndexRecommendation{
+		{
+			Table:   "customers",
+			Columns: []string{"email"},
+			SQL:     `CREATE INDEX "idx_customers_email_2" ON "customers" ("email");`,
+			Queries: []int{0},
+		},
+	}
+
+	got, err := meta.RecommendIndexes(db, queries)
+	if err != nil {
+		t.Fatalf("RecommendIndexes returned error %s", err)
+	}
+
+	compareStructSlices(t, "RecommendIndexes", "recommendation", "recommendations", exp, got)
+
+	// The recommended statement should run as-is.
+	exec(t, db, []string{got[0].SQL})
+}
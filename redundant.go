@@ -0,0 +1,251 @@
+package sqlitemeta
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// A RedundantIndex is an index that can be dropped without
+// affecting query performance or data integrity because another
+// index on the same table makes it unnecessary.
+type RedundantIndex struct {
+	Table     string
+	Name      string
+	CoveredBy string // The name of the index that makes this one redundant.
+
+	// Duplicate is true if the index has exactly the same
+	// columns as CoveredBy. Otherwise its columns are a strict
+	// left prefix of CoveredBy's columns.
+	Duplicate bool
+}
+
+// RedundantIndexes returns the redundant indexes in the main
+// database, sorted by table and index name. Use the
+// Schema.RedundantIndexes method to query other databases.
+func RedundantIndexes(db Querier) ([]RedundantIndex, error) {
+	return Main.RedundantIndexes(db)
+}
+
+// RedundantIndexes returns the redundant indexes in this Schema,
+// sorted by table and index name.
+//
+// An index is redundant if another index on the same table has
+// the same key columns, or begins with the same key columns, in
+// the same order and with the same sort orders and collations.
+// The following indexes are never reported:
+//
+//   - Indexes created by SQLite to enforce PRIMARY KEY and
+//     UNIQUE constraints. These cannot be dropped.
+//
+//   - UNIQUE indexes that are a strict prefix of another index.
+//     The uniqueness constraint is stronger than that of the
+//     longer index.
+//
+//   - UNIQUE indexes that duplicate a non-UNIQUE index. In this
+//     case, the non-UNIQUE index is reported instead.
+//
+//   - Partial indexes, unless the covering index is a partial
+//     index with an identical WHERE clause.
+//
+//   - Indexes on expressions, unless the expressions occur
+//     after the columns that make the index redundant.
+//
+// When two indexes are exact duplicates, only one of them is
+// reported, preferring to keep constraint indexes, then UNIQUE
+// indexes, then the index whose name sorts first.
+func (s *Schema) RedundantIndexes(db Querier) ([]RedundantIndex, error) {
+
+	objects, err := s.masterObjects(db)
+	if err != nil {
+		return nil, fmt.Errorf("could not get redundant indexes: %s", err)
+	}
+
+	predicates := map[string]string{}
+	for _, obj := range objects {
+		if obj.Type == "index" {
+			predicates[sqlower(obj.Name)] = indexPredicate(obj.SQL.String)
+		}
+	}
+
+	var redundant []RedundantIndex
+
+	for _, obj := range objects {
+
+		if obj.Type != "table" {
+			continue
+		}
+
+		indexes, err := s.Indexes(db, obj.Name)
+		if err != nil {
+			return nil, fmt.Errorf("could not get redundant indexes: %s", err)
+		}
+
+		var candidates []indexCandidate
+
+		for _, idx := range indexes {
+
+			columns, err := s.IndexColumns(db, idx.Name)
+			if err != nil {
+				return nil, fmt.Errorf("could not get redundant indexes: %s", err)
+			}
+
+			candidates = append(candidates, indexCandidate{
+				Index:     idx,
+				columns:   columns,
+				predicate: predicates[sqlower(idx.Name)],
+			})
+		}
+
+		sort.Sort(byIndexName(candidates))
+
+		for i := range candidates {
+			if other, dup, ok := findCoveringIndex(&candidates[i], candidates); ok {
+				redundant = append(redundant, RedundantIndex{
+					Table:     obj.Name,
+					Name:      candidates[i].Name,
+					CoveredBy: other.Name,
+					Duplicate: dup,
+				})
+			}
+		}
+	}
+
+	return redundant, nil
+}
+
+type indexCandidate struct {
+	Index
+	columns   []IndexColumn
+	predicate string
+}
+
+type byIndexName []indexCandidate
+
+func (c byIndexName) Len() int           { return len(c) }
+func (c byIndexName) Less(i, j int) bool { return sqlower(c[i].Name) < sqlower(c[j].Name) }
+func (c byIndexName) Swap(i, j int)      { c[i], c[j] = c[j], c[i] }
+
+// rank returns a value indicating how important it is to keep
+// an index when it is an exact duplicate of another.
+func (c *indexCandidate) rank() int {
+	switch {
+	case c.Type != IndexTypeUser:
+		return 2
+	case c.IsUnique:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// findCoveringIndex returns the first index in candidates that
+// makes idx redundant, and whether the two are duplicates.
+func findCoveringIndex(idx *indexCandidate, candidates []indexCandidate) (*indexCandidate, bool, bool) {
+
+	if idx.Type != IndexTypeUser {
+		return nil, false, false
+	}
+
+	for i := range candidates {
+
+		other := &candidates[i]
+
+		if other.Name == idx.Name || other.predicate != idx.predicate || !isIndexPrefix(idx.columns, other.columns) {
+			continue
+		}
+
+		if len(idx.columns) < len(other.columns) {
+			if !idx.IsUnique {
+				return other, false, true
+			}
+			continue
+		}
+
+		// The indexes are duplicates. Keep the one with the
+		// higher rank or, if the ranks are equal, the one that
+		// sorts first.
+		if r1, r2 := idx.rank(), other.rank(); r2 > r1 || r2 == r1 && i < indexOf(idx, candidates) {
+			return other, true, true
+		}
+	}
+
+	return nil, false, false
+}
+
+func indexOf(idx *indexCandidate, candidates []indexCandidate) int {
+	for i := range candidates {
+		if &candidates[i] == idx {
+			return i
+		}
+	}
+	return -1
+}
+
+// isIndexPrefix reports whether the columns in a are the same
+// as the leftmost columns in b.
+func isIndexPrefix(a, b []IndexColumn) bool {
+
+	if len(a) == 0 || len(a) > len(b) {
+		return false
+	}
+
+	for i := range a {
+		if !a[i].Name.Valid || !b[i].Name.Valid ||
+			sqlower(a[i].Name.String) != sqlower(b[i].Name.String) ||
+			a[i].Descending != b[i].Descending ||
+			!strings.EqualFold(a[i].Collation, b[i].Collation) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// indexPredicate returns the WHERE clause of a CREATE INDEX
+// statement in a normalised form suitable for comparison, or
+// an empty string if the index is not a partial index.
+func indexPredicate(sql string) string {
+
+	tokens := tokenize(sql)
+
+	// Skip to the end of the indexed column list.
+	depth := 0
+	for i, t := range tokens {
+		switch {
+		case t.isPunct("("):
+			depth++
+		case t.isPunct(")"):
+			depth--
+			if depth == 0 {
+				if i+1 < len(tokens) && tokens[i+1].isWord("WHERE") {
+					return normalizeTokens(tokens[i+2:])
+				}
+				return ""
+			}
+		}
+	}
+
+	return ""
+}
+
+// normalizeTokens joins the given tokens into a string, with
+// keywords and identifiers converted to lowercase and quotes
+// removed from identifiers.
+func normalizeTokens(tokens []token) string {
+
+	var words []string
+
+	for _, t := range tokens {
+		switch t.kind {
+		case tokenWord, tokenIdent:
+			words = append(words, sqlower(t.value()))
+		default:
+			if !t.isPunct(";") {
+				words = append(words, t.text)
+			}
+		}
+	}
+
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,120 @@
+package sqlitemeta_test
+
+import (
+	"database/sql"
+	"testing"
+
+	meta "github.com/deepilla/sqlitemeta"
+)
+
+func TestRedundantIndexes(t *testing.T) {
+	testWithDB(t, testRedundantIndexes)
+}
+
+func testRedundantIndexes(t *testing.T, db *sql.DB) {
+
+	exec(t, db, []string{
+		"DROP TABLE IF EXISTS a",
+		"DROP TABLE IF EXISTS b",
+
+		`CREATE TABLE a (
+            x,
+            y,
+            z TEXT UNIQUE,
+            w
+        )`,
+
+		// Duplicates. Only one should be reported.
+		"CREATE INDEX idx_a_x_1 ON a(x, y)",
+		"CREATE INDEX idx_a_x_2 ON a(x, y)",
+
+		// Left prefix of idx_a_x_1.
+		"CREATE INDEX idx_a_x_3 ON a(x)",
+
+		// Different sort order. Not redundant.
+		"CREATE INDEX idx_a_x_4 ON a(x DESC)",
+
+		// Different collation. Not redundant.
+		"CREATE INDEX idx_a_x_5 ON a(x COLLATE NOCASE)",
+
+		// Unique prefix. Not redundant.
+		"CREATE UNIQUE INDEX idx_a_y_1 ON a(y)",
+		"CREATE INDEX idx_a_y_2 ON a(y, z)",
+
+		// Duplicates the UNIQUE constraint on z.
+		"CREATE INDEX idx_a_z_1 ON a(z)",
+
+		// Unique duplicate of a non-unique index. The
+		// non-unique index should be reported.
+		"CREATE INDEX idx_a_w_1 ON a(w, x)",
+		"CREATE UNIQUE INDEX idx_a_w_2 ON a(w, x)",
+
+		// Partial indexes.
+		"CREATE INDEX idx_a_partial_1 ON a(w) WHERE x > 10",
+		`CREATE INDEX idx_a_partial_2 ON a("w", z) WHERE "X" > 10`,
+		"CREATE INDEX idx_a_partial_3 ON a(w) WHERE x > 20",
+
+		`CREATE TABLE b (
+            x,
+            y,
+            PRIMARY KEY (x, y)
+        ) WITHOUT ROWID`,
+
+		// Expression index. Not redundant.
+		"CREATE INDEX idx_b_expr ON b(x + y)",
+
+		// Left prefix of the primary key.
+		"CREATE INDEX idx_b_x ON b(x)",
+	})
+
+	exp := []meta.RedundantIndex{
+		{
+			Table:     "a",
+			Name:      "idx_a_partial_1",
+			CoveredBy: "idx_a_partial_2",
+		},
+		{
+			Table:     "a",
+			Name:      "idx_a_w_1",
+			CoveredBy: "idx_a_w_2",
+			Duplicate: true,
+		},
+		{
+			Table:     "a",
+			Name:      "idx_a_x_2",
+			CoveredBy: "idx_a_x_1",
+			Duplicate: true,
+		},
+		{
+			Table:     "a",
+			Name:      "idx_a_x_3",
+			CoveredBy: "idx_a_x_1",
+		},
+		{
+			Table:     "a",
+			Name:      "idx_a_z_1",
+			CoveredBy: "sqlite_autoindex_a_1",
+			Duplicate: true,
+		},
+		{
+			Table:     "b",
+			Name:      "idx_b_x",
+			CoveredBy: "sqlite_autoindex_b_1",
+		},
+	}
+
+	funcs := []func(meta.Querier) ([]meta.RedundantIndex, error){
+		meta.RedundantIndexes,
+		meta.Main.RedundantIndexes,
+	}
+
+	for i, f := range funcs {
+
+		got, err := f(db)
+		if err != nil {
+			t.Fatalf("RedundantIndexes (%d) returned error %s", i+1, err)
+		}
+
+		compareStructSlices(t, "RedundantIndexes", "index", "index(es)", exp, got)
+	}
+}
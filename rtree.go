@@ -0,0 +1,127 @@
+package sqlitemeta
+
+import "fmt"
+
+// An RTreeTable describes an R*Tree virtual table.
+type RTreeTable struct {
+	Name   string
+	Module string // "rtree" or "rtree_i32".
+
+	// IDColumn is the name of the 64-bit integer primary key
+	// column.
+	IDColumn string
+
+	// Dimensions lists the minimum and maximum coordinate
+	// columns for each dimension, in order.
+	Dimensions []RTreeDimension
+
+	// Integer is true if coordinates are stored as 32-bit
+	// integers (rtree_i32). Otherwise they are stored as 32-bit
+	// floating point values.
+	Integer bool
+
+	// AuxColumns lists the auxiliary columns, which store
+	// arbitrary data alongside each entry (SQLite 3.24.0).
+	AuxColumns []string
+
+	// ShadowTables lists the tables that SQLite uses to store
+	// the R*Tree, e.g. "shapes_node".
+	ShadowTables []string
+}
+
+// An RTreeDimension is a pair of coordinate columns in an
+// R*Tree table.
+type RTreeDimension struct {
+	Min string
+	Max string
+}
+
+// RTree returns a description of the given R*Tree table in the
+// main database. Use the Schema.RTree method to query other
+// databases.
+//
+// If the table does not exist or is not an R*Tree table, RTree
+// returns nil.
+func RTree(db Querier, tableName string) (*RTreeTable, error) {
+	return Main.RTree(db, tableName)
+}
+
+// RTree returns a description of the given R*Tree table in this
+// Schema. The description is derived from the table's CREATE
+// VIRTUAL TABLE statement, so the R*Tree module itself does not
+// need to be available.
+//
+// If the table does not exist or is not an R*Tree table, RTree
+// returns nil.
+func (s *Schema) RTree(db Querier, tableName string) (*RTreeTable, error) {
+
+	if s.name == "" {
+		return Main.RTree(db, tableName)
+	}
+
+	objects, err := s.masterObjects(db)
+	if err != nil {
+		return nil, fmt.Errorf("could not get R*Tree table %s: %s", tableName, err)
+	}
+
+	for _, obj := range objects {
+		if obj.Type == "table" && sqlower(obj.Name) == sqlower(tableName) && obj.SQL.Valid {
+			return parseRTreeTable(obj.Name, obj.SQL.String, objects), nil
+		}
+	}
+
+	return nil, nil
+}
+
+// parseRTreeTable parses a CREATE VIRTUAL TABLE statement. It
+// returns nil if the statement does not create an R*Tree table.
+func parseRTreeTable(name, sql string, objects []masterObject) *RTreeTable {
+
+	module, args := virtualTableArgs(sql)
+
+	module = sqlower(module)
+	if module != "rtree" && module != "rtree_i32" {
+		return nil
+	}
+
+	t := &RTreeTable{
+		Name:    name,
+		Module:  module,
+		Integer: module == "rtree_i32",
+	}
+
+	var coords []string
+
+	for _, arg := range args {
+
+		if len(arg) == 0 {
+			continue
+		}
+
+		// Auxiliary column names are prefixed with a plus sign.
+		if arg[0].isPunct("+") {
+			if len(arg) > 1 {
+				t.AuxColumns = append(t.AuxColumns, arg[1].value())
+			}
+			continue
+		}
+
+		if t.IDColumn == "" {
+			t.IDColumn = arg[0].value()
+			continue
+		}
+
+		coords = append(coords, arg[0].value())
+	}
+
+	for i := 0; i+1 < len(coords); i += 2 {
+		t.Dimensions = append(t.Dimensions, RTreeDimension{
+			Min: coords[i],
+			Max: coords[i+1],
+		})
+	}
+
+	t.ShadowTables = shadowTables(name, module, objects)
+
+	return t
+}
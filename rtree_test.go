@@ -0,0 +1,118 @@
+package sqlitemeta_test
+
+import (
+	"database/sql"
+	"reflect"
+	"strings"
+	"testing"
+
+	meta "github.com/deepilla/sqlitemeta"
+)
+
+func TestRTree(t *testing.T) {
+	testWithDB(t, testRTree)
+}
+
+func testRTree(t *testing.T, db *sql.DB) {
+
+	exec(t, db, []string{
+		"DROP TABLE IF EXISTS plain",
+		"CREATE TABLE plain (x)",
+	})
+
+	_, err := db.Exec("CREATE VIRTUAL TABLE IF NOT EXISTS shapes USING rtree(id, minX, maxX, minY, maxY, +name, +color TEXT)")
+	if err != nil && strings.Contains(err.Error(), "no such module") {
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Fatalf("Could not create R*Tree table: %s", err)
+	}
+
+	exec(t, db, []string{
+		"CREATE VIRTUAL TABLE IF NOT EXISTS grid USING rtree_i32(\"cell id\", x0, x1)",
+	})
+
+	defer exec(t, db, []string{
+		"DROP TABLE shapes",
+		"DROP TABLE grid",
+	})
+
+	tbl, err := meta.RTree(db, "SHAPES")
+	if err != nil {
+		t.Fatalf("RTree returned error %s", err)
+	}
+
+	exp := &meta.RTreeTable{
+		Name:     "shapes",
+		Module:   "rtree",
+		IDColumn: "id",
+		Dimensions: []meta.RTreeDimension{
+			{Min: "minX", Max: "maxX"},
+			{Min: "minY", Max: "maxY"},
+		},
+		AuxColumns:   []string{"name", "color"},
+		ShadowTables: []string{"shapes_node", "shapes_parent", "shapes_rowid"},
+	}
+
+	if !reflect.DeepEqual(exp, tbl) {
+		t.Errorf("Expected R*Tree table %+v, got %+v", exp, tbl)
+	}
+
+	tbl, err = meta.RTree(db, "grid")
+	if err != nil {
+		t.Fatalf("RTree returned error %s", err)
+	}
+
+	exp = &meta.RTreeTable{
+		Name:     "grid",
+		Module:   "rtree_i32",
+		IDColumn: "cell id",
+		Dimensions: []meta.RTreeDimension{
+			{Min: "x0", Max: "x1"},
+		},
+		Integer:      true,
+		ShadowTables: []string{"grid_node", "grid_parent", "grid_rowid"},
+	}
+
+	if !reflect.DeepEqual(exp, tbl) {
+		t.Errorf("Expected R*Tree table %+v, got %+v", exp, tbl)
+	}
+
+	for _, name := range []string{"plain", "shapes_node", "xxxxx"} {
+		tbl, err = meta.RTree(db, name)
+		if err != nil {
+			t.Fatalf("RTree returned error %s", err)
+		}
+		if tbl != nil {
+			t.Errorf("Expected nil for table %s, got %+v", name, tbl)
+		}
+	}
+
+	_, err = meta.DB("xxxxx").RTree(db, "shapes")
+	if exp := "could not get R*Tree table shapes: unknown database 'xxxxx'"; err == nil || err.Error() != exp {
+		t.Errorf("Expected error %q, got %v", exp, err)
+	}
+
+	names, err := meta.ShadowTableNames(db)
+	if err != nil {
+		t.Fatalf("ShadowTableNames returned error %s", err)
+	}
+
+	expNames := []string{
+		"grid_node",
+		"grid_parent",
+		"grid_rowid",
+		"shapes_node",
+		"shapes_parent",
+		"shapes_rowid",
+	}
+
+	if !equalStringSlices(expNames, names) {
+		t.Errorf("Expected shadow tables %v, got %v", expNames, names)
+	}
+
+	_, err = meta.DB("xxxxx").ShadowTableNames(db)
+	if exp := "could not get shadow table names: unknown database 'xxxxx'"; err == nil || err.Error() != exp {
+		t.Errorf("Expected error %q, got %v", exp, err)
+	}
+}
@@ -0,0 +1,206 @@
+package sqlitemeta
+
+import (
+	"context"
+	"fmt"
+)
+
+// A TableSequence is the AUTOINCREMENT state of a table, as
+// stored in the sqlite_sequence table.
+type TableSequence struct {
+	Table string
+
+	// Value is the largest rowid that has ever been used in
+	// the table. The next row inserted without an explicit
+	// rowid gets a rowid of Value+1.
+	Value int64
+}
+
+// Sequences returns the AUTOINCREMENT state of the tables in the
+// main database, sorted by table name. Use the Schema.Sequences
+// method to query other databases.
+func Sequences(db Querier) ([]TableSequence, error) {
+	return Main.Sequences(db)
+}
+
+// Sequences returns the AUTOINCREMENT state of the tables in
+// this Schema, sorted by table name.
+//
+// SQLite only stores a sequence value for an AUTOINCREMENT table
+// once a row has been inserted into it, so tables that have
+// never contained any rows are not included.
+func (s *Schema) Sequences(db Querier) ([]TableSequence, error) {
+
+	ok, err := s.hasTable(db, "sqlite_sequence")
+	if err != nil {
+		return nil, fmt.Errorf("could not get sequences: %s", err)
+	}
+	if !ok {
+		return []TableSequence{}, nil
+	}
+
+	tableName, err := s.qualify(db, "sqlite_sequence")
+	if err != nil {
+		return nil, fmt.Errorf("could not get sequences: %s", err)
+	}
+
+	q := fmt.Sprintf("SELECT name, seq FROM %s ORDER BY LOWER(name)", tableName)
+
+	var seq TableSequence
+	sequences := []TableSequence{}
+
+	err = scanRows(db, q, nil, func() {
+		sequences = append(sequences, seq)
+	}, &seq.Table, &seq.Value)
+	if err != nil {
+		return nil, fmt.Errorf("could not get sequences: %s", err)
+	}
+
+	return sequences, nil
+}
+
+// Sequence returns the AUTOINCREMENT value of the given table in
+// the main database. Use the Schema.Sequence method to query
+// other databases.
+func Sequence(db Querier, tableName string) (int64, error) {
+	return Main.Sequence(db, tableName)
+}
+
+// Sequence returns the AUTOINCREMENT value of the given table in
+// this Schema, i.e. the largest rowid that has ever been used in
+// the table. It returns zero if no rows have been inserted into
+// the table.
+//
+// If the table does not exist or was not declared with
+// AUTOINCREMENT, Sequence returns an error.
+func (s *Schema) Sequence(db Querier, tableName string) (int64, error) {
+
+	name, err := s.autoincrementTable(db, tableName)
+	if err != nil {
+		return 0, fmt.Errorf("could not get sequence for table %s: %s", tableName, err)
+	}
+
+	sequences, err := s.Sequences(db)
+	if err != nil {
+		return 0, fmt.Errorf("could not get sequence for table %s: %s", tableName, err)
+	}
+
+	for _, seq := range sequences {
+		if sqlower(seq.Table) == sqlower(name) {
+			return seq.Value, nil
+		}
+	}
+
+	return 0, nil
+}
+
+// ResetSequence sets the AUTOINCREMENT value of the given table
+// in the main database. Use the Schema.ResetSequence method to
+// update other databases.
+func ResetSequence(db TxBeginner, tableName string, value int64) error {
+	return Main.ResetSequence(db, tableName, value)
+}
+
+// ResetSequence sets the AUTOINCREMENT value of the given table
+// in this Schema, so that the next row inserted without an
+// explicit rowid gets a rowid of value+1. This is useful for
+// preserving ID ranges when migrating data.
+//
+// To prevent rowids from being reused, the value cannot be less
+// than the largest rowid currently in the table. The check and
+// the update are performed in a single transaction.
+//
+// If the table does not exist or was not declared with
+// AUTOINCREMENT, ResetSequence returns an error.
+//
+// To reset the sequence of a table in a database attached with
+// Attach, pass the connection that it was attached to.
+func (s *Schema) ResetSequence(db TxBeginner, tableName string, value int64) error {
+
+	name, err := s.autoincrementTable(db, tableName)
+	if err != nil {
+		return fmt.Errorf("could not reset sequence for table %s: %s", tableName, err)
+	}
+
+	table, err := s.qualify(db, quoteIdent(name))
+	if err != nil {
+		return fmt.Errorf("could not reset sequence for table %s: %s", tableName, err)
+	}
+
+	sequence, err := s.qualify(db, "sqlite_sequence")
+	if err != nil {
+		return fmt.Errorf("could not reset sequence for table %s: %s", tableName, err)
+	}
+
+	err = s.resetSequence(db, table, sequence, name, value)
+	if err != nil {
+		return fmt.Errorf("could not reset sequence for table %s: %s", tableName, err)
+	}
+
+	return nil
+}
+
+func (s *Schema) resetSequence(db TxBeginner, table, sequence, name string, value int64) (err error) {
+
+	tx, err := db.BeginTx(context.Background(), nil)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+			return
+		}
+		err = tx.Commit()
+	}()
+
+	var max int64
+
+	err = tx.QueryRow("SELECT COALESCE(MAX(rowid), 0) FROM " + table).Scan(&max)
+	if err != nil {
+		return err
+	}
+
+	if value < max {
+		return fmt.Errorf("value %d is less than the largest rowid %d", value, max)
+	}
+
+	res, err := tx.Exec("UPDATE "+sequence+" SET seq = ? WHERE name = ?", value, name)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		_, err = tx.Exec("INSERT INTO "+sequence+" (name, seq) VALUES (?, ?)", name, value)
+	}
+
+	return err
+}
+
+// autoincrementTable returns the name of the given table, as
+// stored in sqlite_master. It returns an error if the table
+// does not exist or was not declared with AUTOINCREMENT.
+func (s *Schema) autoincrementTable(db Querier, tableName string) (string, error) {
+
+	objects, err := s.masterObjects(db)
+	if err != nil {
+		return "", err
+	}
+
+	for _, obj := range objects {
+		if obj.Type == "table" && sqlower(obj.Name) == sqlower(tableName) {
+			if !hasWord(tokenize(obj.SQL.String), "AUTOINCREMENT") {
+				return "", fmt.Errorf("table does not use AUTOINCREMENT")
+			}
+			return obj.Name, nil
+		}
+	}
+
+	return "", fmt.Errorf("no such table")
+}
@@ -0,0 +1,217 @@
+package sqlitemeta_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	meta "github.com/deepilla/sqlitemeta"
+)
+
+func TestSequences(t *testing.T) {
+	testWithDB(t, testSequences)
+}
+
+func testSequences(t *testing.T, db *sql.DB) {
+
+	sequences, err := meta.Sequences(db)
+	if err != nil {
+		t.Fatalf("Sequences returned error %s", err)
+	}
+	if len(sequences) != 0 {
+		t.Errorf("Expected no sequences, got %v", sequences)
+	}
+
+	exec(t, db, []string{
+		"DROP TABLE IF EXISTS users",
+		"DROP TABLE IF EXISTS Orders",
+		"DROP TABLE IF EXISTS empty",
+		"DROP TABLE IF EXISTS plain",
+		"CREATE TABLE users (id INTEGER PRIMARY KEY AUTOINCREMENT, name)",
+		"CREATE TABLE Orders (id INTEGER PRIMARY KEY AUTOINCREMENT, total)",
+		"CREATE TABLE empty (id INTEGER PRIMARY KEY AUTOINCREMENT)",
+		"CREATE TABLE plain (id INTEGER PRIMARY KEY)",
+		"INSERT INTO users (name) VALUES ('a'), ('b'), ('c')",
+		"DELETE FROM users WHERE id = 3",
+		"INSERT INTO Orders (id, total) VALUES (100, 1)",
+	})
+
+	sequences, err = meta.Sequences(db)
+	if err != nil {
+		t.Fatalf("Sequences returned error %s", err)
+	}
+
+	exp := []meta.TableSequence{
+		{
+			Table: "Orders",
+			Value: 100,
+		},
+		{
+			Table: "users",
+			Value: 3,
+		},
+	}
+
+	compareStructSlices(t, "", "sequence", "sequences", exp, sequences)
+
+	seqs := []struct {
+		Table string
+		Value int64
+	}{
+		{"USERS", 3},
+		{"orders", 100},
+		{"empty", 0},
+	}
+
+	for _, test := range seqs {
+		got, err := meta.Main.Sequence(db, test.Table)
+		if err != nil {
+			t.Fatalf("Sequence returned error %s", err)
+		}
+		if got != test.Value {
+			t.Errorf("%s: Expected sequence %d, got %d", test.Table, test.Value, got)
+		}
+	}
+
+	errs := []struct {
+		Func  func() error
+		Error string
+	}{
+		{
+			Func: func() error {
+				_, err := meta.Sequence(db, "plain")
+				return err
+			},
+			Error: "could not get sequence for table plain: table does not use AUTOINCREMENT",
+		},
+		{
+			Func: func() error {
+				_, err := meta.Sequence(db, "xxxxx")
+				return err
+			},
+			Error: "could not get sequence for table xxxxx: no such table",
+		},
+		{
+			Func: func() error {
+				_, err := meta.DB("xxxxx").Sequences(db)
+				return err
+			},
+			Error: "could not get sequences: unknown database 'xxxxx'",
+		},
+		{
+			Func: func() error {
+				return meta.ResetSequence(db, "users", 1)
+			},
+			Error: "could not reset sequence for table users: value 1 is less than the largest rowid 2",
+		},
+		{
+			Func: func() error {
+				return meta.ResetSequence(db, "plain", 10)
+			},
+			Error: "could not reset sequence for table plain: table does not use AUTOINCREMENT",
+		},
+	}
+
+	for _, test := range errs {
+		if err := test.Func(); err == nil || err.Error() != test.Error {
+			t.Errorf("Expected error %q, got %v", test.Error, err)
+		}
+	}
+
+	// The sequence can be lowered as long as it isn't less than
+	// the largest rowid, and set for tables without a sequence.
+	resets := []struct {
+		Table string
+		Value int64
+	}{
+		{"users", 2},
+		{"Orders", 500},
+		{"empty", 1000},
+	}
+
+	for _, test := range resets {
+
+		err := meta.ResetSequence(db, test.Table, test.Value)
+		if err != nil {
+			t.Fatalf("ResetSequence returned error %s", err)
+		}
+
+		got, err := meta.Sequence(db, test.Table)
+		if err != nil {
+			t.Fatalf("Sequence returned error %s", err)
+		}
+		if got != test.Value {
+			t.Errorf("%s: Expected sequence %d after reset, got %d", test.Table, test.Value, got)
+		}
+	}
+
+	exec(t, db, []string{
+		"INSERT INTO empty DEFAULT VALUES",
+	})
+
+	var id int64
+	if err := db.QueryRow("SELECT MAX(id) FROM empty").Scan(&id); err != nil {
+		t.Fatalf("Could not query table empty: %s", err)
+	}
+	if id != 1001 {
+		t.Errorf("Expected next id 1001, got %d", id)
+	}
+}
+
+func TestResetSequenceAttached(t *testing.T) {
+	testWithDB(t, testResetSequenceAttached)
+}
+
+func testResetSequenceAttached(t *testing.T, db *sql.DB) {
+
+	ctx := context.Background()
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatalf("Could not get connection: %s", err)
+	}
+	defer conn.Close()
+
+	aux, err := meta.Attach(ctx, conn, ":memory:", "aux")
+	if err != nil {
+		t.Fatalf("Attach returned error %s", err)
+	}
+	defer meta.Detach(ctx, conn, "aux")
+
+	for _, q := range []string{
+		"CREATE TABLE aux.items (id INTEGER PRIMARY KEY AUTOINCREMENT, name)",
+		"INSERT INTO aux.items (name) VALUES ('a'), ('b')",
+	} {
+		if _, err := conn.ExecContext(ctx, q); err != nil {
+			t.Fatalf("Could not run %q: %s", q, err)
+		}
+	}
+
+	// Other connections in the pool can't see the attached
+	// database so the reset must run on this connection.
+	if err := aux.ResetSequence(conn, "items", 50); err != nil {
+		t.Fatalf("ResetSequence returned error %s", err)
+	}
+
+	value, err := aux.Sequence(conn, "items")
+	if err != nil {
+		t.Fatalf("Sequence returned error %s", err)
+	}
+	if value != 50 {
+		t.Errorf("Expected sequence 50, got %d", value)
+	}
+
+	res, err := conn.ExecContext(ctx, "INSERT INTO aux.items (name) VALUES ('c')")
+	if err != nil {
+		t.Fatalf("Could not insert row: %s", err)
+	}
+
+	if id, err := res.LastInsertId(); err != nil || id != 51 {
+		t.Errorf("Expected next id 51, got %d (%v)", id, err)
+	}
+
+	err = aux.ResetSequence(conn, "items", 1)
+	if err == nil {
+		t.Errorf("Expected an error resetting the sequence below the largest rowid")
+	}
+}
@@ -3,6 +3,7 @@ package sqlitemeta
 import (
 	"database/sql"
 	"fmt"
+	"sort"
 	"strings"
 )
 
@@ -13,11 +14,18 @@ type Schema struct {
 }
 
 // DB returns a Schema with the given name. It does not verify
-// that a database with this name actually exists.
+// that a database with this name actually exists. Use the
+// Schema.Exists method to check, or Attach to attach a database
+// and get a verified Schema.
 func DB(name string) *Schema {
 	return &Schema{name}
 }
 
+// Name returns the name of the Schema.
+func (s *Schema) Name() string {
+	return s.name
+}
+
 // Main is the database that was used to open a database
 // connection.
 var Main = DB("main")
@@ -30,66 +38,73 @@ var noSchema = &Schema{}
 
 // SchemaNames returns the names of the databases attached to
 // the given database connection, sorted alphabetically.
-func SchemaNames(db *sql.DB) ([]string, error) {
+func SchemaNames(db Querier) ([]string, error) {
 
-	names, err := queryStrings(db, "SELECT name FROM pragma_database_list ORDER BY name")
+	databases, err := databaseList(db)
 	if err != nil {
 		return nil, fmt.Errorf("could not get schema names: %s", err)
 	}
 
+	var names []string
+	for _, d := range databases {
+		names = append(names, d.Name)
+	}
+
+	sort.Strings(names)
+
 	return names, nil
 }
 
 // TableNames returns the names of the tables in the main
 // database, sorted alphabetically. Use the Schema.TableNames
 // method to query other databases.
-func TableNames(db *sql.DB) ([]string, error) {
-	return noSchema.TableNames(db)
+func TableNames(db Querier, opts ...ListOption) ([]string, error) {
+	return noSchema.TableNames(db, opts...)
 }
 
 // TableNames returns the names of the tables in this Schema,
 // sorted alphabetically.
-func (s *Schema) TableNames(db *sql.DB) ([]string, error) {
-	return s.masterTableNames(db, "table")
+func (s *Schema) TableNames(db Querier, opts ...ListOption) ([]string, error) {
+	return s.masterTableNames(db, "table", opts)
 }
 
 // ViewNames returns the names of the views in the main database,
 // sorted alphabetically. Use the Schema.ViewNames method to query
 // other databases.
-func ViewNames(db *sql.DB) ([]string, error) {
-	return noSchema.ViewNames(db)
+func ViewNames(db Querier, opts ...ListOption) ([]string, error) {
+	return noSchema.ViewNames(db, opts...)
 }
 
 // ViewNames returns the names of the views in this Schema, sorted
 // alphabetically.
-func (s *Schema) ViewNames(db *sql.DB) ([]string, error) {
-	return s.masterTableNames(db, "view")
+func (s *Schema) ViewNames(db Querier, opts ...ListOption) ([]string, error) {
+	return s.masterTableNames(db, "view", opts)
 }
 
 // TriggerNames returns the names of the triggers in the main
 // database, sorted alphabetically. Use the Schema.TriggerNames
 // method to query other databases.
-func TriggerNames(db *sql.DB) ([]string, error) {
-	return noSchema.TriggerNames(db)
+func TriggerNames(db Querier, opts ...ListOption) ([]string, error) {
+	return noSchema.TriggerNames(db, opts...)
 }
 
 // TriggerNames returns the names of the triggers in this Schema,
 // sorted alphabetically.
-func (s *Schema) TriggerNames(db *sql.DB) ([]string, error) {
-	return s.masterTableNames(db, "trigger")
+func (s *Schema) TriggerNames(db Querier, opts ...ListOption) ([]string, error) {
+	return s.masterTableNames(db, "trigger", opts)
 }
 
 // IndexNames returns the names of the indexes in the main
 // database, sorted alphabetically. Use the Schema.IndexNames
 // method to query other databases.
-func IndexNames(db *sql.DB) ([]string, error) {
-	return noSchema.IndexNames(db)
+func IndexNames(db Querier, opts ...ListOption) ([]string, error) {
+	return noSchema.IndexNames(db, opts...)
 }
 
 // IndexNames returns the names of the indexes in this Schema,
 // sorted alphabetically.
-func (s *Schema) IndexNames(db *sql.DB) ([]string, error) {
-	return s.masterTableNames(db, "index")
+func (s *Schema) IndexNames(db Querier, opts ...ListOption) ([]string, error) {
+	return s.masterTableNames(db, "index", opts)
 }
 
 // Column represents a column in a table.
@@ -107,7 +122,7 @@ type Column struct {
 // If no such table is found in any of the available databases
 // (see Multiple Databases above), Columns returns an empty
 // slice.
-func Columns(db *sql.DB, tableName string) ([]Column, error) {
+func Columns(db Querier, tableName string) ([]Column, error) {
 	return noSchema.Columns(db, tableName)
 }
 
@@ -115,7 +130,7 @@ func Columns(db *sql.DB, tableName string) ([]Column, error) {
 //
 // If no such table is found in this Schema, Columns returns
 // an empty slice.
-func (s *Schema) Columns(db *sql.DB, tableName string) ([]Column, error) {
+func (s *Schema) Columns(db Querier, tableName string) ([]Column, error) {
 
 	params := []interface{}{tableName}
 	if s.name != "" {
@@ -135,9 +150,19 @@ func (s *Schema) Columns(db *sql.DB, tableName string) ([]Column, error) {
 		ORDER BY
 			cid`
 
+	var c Column
 	var columns []Column
 
-	err := queryRows(&columns, db, q, params...)
+	appendColumn := func() {
+		columns = append(columns, c)
+	}
+
+	err := s.queryPragma(db, func() error {
+		return scanRows(db, q, params, appendColumn, c.dest()...)
+	}, func(prefix string) error {
+		columns = nil
+		return scanRows(db, "PRAGMA "+prefix+"table_info("+quoteIdent(tableName)+")", nil, appendColumn, c.dest()...)
+	})
 	if err != nil {
 		return nil, fmt.Errorf("could not get columns for table %s: %s", tableName, err)
 	}
@@ -145,6 +170,12 @@ func (s *Schema) Columns(db *sql.DB, tableName string) ([]Column, error) {
 	return columns, nil
 }
 
+// dest returns the scan destinations for a row returned by
+// pragma_table_info.
+func (c *Column) dest() []interface{} {
+	return []interface{}{&c.ID, &c.Name, &c.Type, &c.NotNull, &c.Default, &c.PrimaryKey}
+}
+
 // A ForeignKeyAction describes what happens to the child rows
 // of a foreign key when the parent key values are updated or
 // deleted.
@@ -204,6 +235,25 @@ func (v *ForeignKeyAction) Scan(src interface{}) error {
 	return nil
 }
 
+// String returns the SQL representation of a ForeignKeyAction,
+// e.g. "SET NULL".
+func (v ForeignKeyAction) String() string {
+	switch v {
+	case ForeignKeyActionNone:
+		return "NO ACTION"
+	case ForeignKeyActionRestrict:
+		return "RESTRICT"
+	case ForeignKeyActionSetNull:
+		return "SET NULL"
+	case ForeignKeyActionSetDefault:
+		return "SET DEFAULT"
+	case ForeignKeyActionCascade:
+		return "CASCADE"
+	default:
+		return fmt.Sprintf("ForeignKeyAction(%d)", uint(v))
+	}
+}
+
 // ForeignKey represents a foreign key constraint.
 type ForeignKey struct {
 	ID          int
@@ -220,7 +270,7 @@ type ForeignKey struct {
 // If no such table is found in any of the available databases
 // (see Multiple Databases above), ForeignKeys returns an empty
 // slice.
-func ForeignKeys(db *sql.DB, tableName string) ([]ForeignKey, error) {
+func ForeignKeys(db Querier, tableName string) ([]ForeignKey, error) {
 	return noSchema.ForeignKeys(db, tableName)
 }
 
@@ -229,7 +279,7 @@ func ForeignKeys(db *sql.DB, tableName string) ([]ForeignKey, error) {
 //
 // If no such table is found in this Schema, ForeignKeys returns
 // an empty slice.
-func (s *Schema) ForeignKeys(db *sql.DB, tableName string) ([]ForeignKey, error) {
+func (s *Schema) ForeignKeys(db Querier, tableName string) ([]ForeignKey, error) {
 
 	params := []interface{}{tableName}
 	if s.name != "" {
@@ -249,20 +299,29 @@ func (s *Schema) ForeignKeys(db *sql.DB, tableName string) ([]ForeignKey, error)
 		ORDER BY
 			id, seq`
 
-	var rows []struct {
-		ID       int
-		Table    string
-		From     string
-		To       sql.NullString
-		OnUpdate ForeignKeyAction
-		OnDelete ForeignKeyAction
-	}
-
-	err := queryRows(&rows, db, q, params...)
+	var r foreignKeyRow
+	var rows []foreignKeyRow
+
+	err := s.queryPragma(db, func() error {
+		return scanRows(db, q, params, func() {
+			rows = append(rows, r)
+		}, r.dest()...)
+	}, func(prefix string) error {
+		var err error
+		rows, err = legacyForeignKeyRows(db, prefix, tableName)
+		return err
+	})
 	if err != nil {
 		return nil, fmt.Errorf("could not get foreign keys for table %s: %s", tableName, err)
 	}
 
+	return foreignKeysFromRows(rows), nil
+}
+
+// foreignKeysFromRows combines the rows returned by
+// pragma_foreign_key_list into ForeignKeys.
+func foreignKeysFromRows(rows []foreignKeyRow) []ForeignKey {
+
 	var fk *ForeignKey
 	var foreignKeys []ForeignKey
 
@@ -284,7 +343,20 @@ func (s *Schema) ForeignKeys(db *sql.DB, tableName string) ([]ForeignKey, error)
 		fk.ParentKey = append(fk.ParentKey, r.To)
 	}
 
-	return foreignKeys, nil
+	return foreignKeys
+}
+
+type foreignKeyRow struct {
+	ID       int
+	Table    string
+	From     string
+	To       sql.NullString
+	OnUpdate ForeignKeyAction
+	OnDelete ForeignKeyAction
+}
+
+func (r *foreignKeyRow) dest() []interface{} {
+	return []interface{}{&r.ID, &r.Table, &r.From, &r.To, &r.OnUpdate, &r.OnDelete}
 }
 
 // IndexType indicates how an index was created.
@@ -326,6 +398,20 @@ func (t *IndexType) Scan(src interface{}) error {
 	return nil
 }
 
+// String returns a description of an IndexType, e.g. "unique".
+func (t IndexType) String() string {
+	switch t {
+	case IndexTypeUser:
+		return "user"
+	case IndexTypeUnique:
+		return "unique"
+	case IndexTypePrimaryKey:
+		return "primary key"
+	default:
+		return fmt.Sprintf("IndexType(%d)", uint(t))
+	}
+}
+
 // Index represents an index on a table.
 type Index struct {
 	Name        string
@@ -340,7 +426,7 @@ type Index struct {
 // If no such table is found in any of the available databases
 // (see Multiple Databases above), Indexes returns an empty
 // slice.
-func Indexes(db *sql.DB, tableName string) ([]Index, error) {
+func Indexes(db Querier, tableName string) ([]Index, error) {
 	return noSchema.Indexes(db, tableName)
 }
 
@@ -348,7 +434,7 @@ func Indexes(db *sql.DB, tableName string) ([]Index, error) {
 //
 // If no such table is found in this Schema, Indexes returns
 // an empty slice.
-func (s *Schema) Indexes(db *sql.DB, tableName string) ([]Index, error) {
+func (s *Schema) Indexes(db Querier, tableName string) ([]Index, error) {
 
 	placeholder := ""
 	params := []interface{}{tableName}
@@ -372,19 +458,29 @@ func (s *Schema) Indexes(db *sql.DB, tableName string) ([]Index, error) {
 		ORDER BY
 			t1.seq, t2.seqno`
 
-	var rows []struct {
-		Name       string
-		Type       IndexType
-		Unique     bool
-		Partial    bool
-		ColumnName sql.NullString
-	}
-
-	err := queryRows(&rows, db, q, params...)
+	var r indexRow
+	var rows []indexRow
+
+	err := s.queryPragma(db, func() error {
+		return scanRows(db, q, params, func() {
+			rows = append(rows, r)
+		}, r.dest()...)
+	}, func(prefix string) error {
+		var err error
+		rows, err = legacyIndexRows(db, prefix, tableName)
+		return err
+	})
 	if err != nil {
 		return nil, fmt.Errorf("could not get indexes for table %s: %s", tableName, err)
 	}
 
+	return indexesFromRows(rows), nil
+}
+
+// indexesFromRows combines the rows returned by
+// pragma_index_list and pragma_index_info into Indexes.
+func indexesFromRows(rows []indexRow) []Index {
+
 	var idx *Index
 	var indexes []Index
 
@@ -405,7 +501,19 @@ func (s *Schema) Indexes(db *sql.DB, tableName string) ([]Index, error) {
 		idx.ColumnNames = append(idx.ColumnNames, r.ColumnName)
 	}
 
-	return indexes, nil
+	return indexes
+}
+
+type indexRow struct {
+	Name       string
+	Type       IndexType
+	Unique     bool
+	Partial    bool
+	ColumnName sql.NullString
+}
+
+func (r *indexRow) dest() []interface{} {
+	return []interface{}{&r.Name, &r.Type, &r.Unique, &r.Partial, &r.ColumnName}
 }
 
 // TableRankRowID is the TableRank of an IndexColumn that
@@ -431,7 +539,7 @@ type IndexColumn struct {
 // If no such index is found in any of the available databases
 // (see Multiple Databases above), IndexColumns returns an empty
 // slice.
-func IndexColumns(db *sql.DB, indexName string) ([]IndexColumn, error) {
+func IndexColumns(db Querier, indexName string) ([]IndexColumn, error) {
 	return noSchema.IndexColumns(db, indexName)
 }
 
@@ -439,7 +547,7 @@ func IndexColumns(db *sql.DB, indexName string) ([]IndexColumn, error) {
 //
 // If no such index is found in this Schema, IndexColumns returns
 // an empty slice.
-func (s *Schema) IndexColumns(db *sql.DB, indexName string) ([]IndexColumn, error) {
+func (s *Schema) IndexColumns(db Querier, indexName string) ([]IndexColumn, error) {
 	return s.indexColumns(db, indexName, false)
 }
 
@@ -451,7 +559,7 @@ func (s *Schema) IndexColumns(db *sql.DB, indexName string) ([]IndexColumn, erro
 // If no such index is found in any of the available databases
 // (see Multiple Databases above), IndexColumnsAux returns an
 // empty slice.
-func IndexColumnsAux(db *sql.DB, indexName string) ([]IndexColumn, error) {
+func IndexColumnsAux(db Querier, indexName string) ([]IndexColumn, error) {
 	return noSchema.IndexColumnsAux(db, indexName)
 }
 
@@ -462,11 +570,11 @@ func IndexColumnsAux(db *sql.DB, indexName string) ([]IndexColumn, error) {
 //
 // If no such index is found in this Schema, IndexColumnsAux
 // returns an empty slice.
-func (s *Schema) IndexColumnsAux(db *sql.DB, indexName string) ([]IndexColumn, error) {
+func (s *Schema) IndexColumnsAux(db Querier, indexName string) ([]IndexColumn, error) {
 	return s.indexColumns(db, indexName, true)
 }
 
-func (s *Schema) indexColumns(db *sql.DB, indexName string, includeAux bool) ([]IndexColumn, error) {
+func (s *Schema) indexColumns(db Querier, indexName string, includeAux bool) ([]IndexColumn, error) {
 
 	params := []interface{}{indexName}
 	if s.name != "" {
@@ -493,9 +601,18 @@ func (s *Schema) indexColumns(db *sql.DB, indexName string, includeAux bool) ([]
 		ORDER BY
 			seqno`
 
+	var c IndexColumn
 	var columns []IndexColumn
 
-	err := queryRows(&columns, db, q, params...)
+	err := s.queryPragma(db, func() error {
+		return scanRows(db, q, params, func() {
+			columns = append(columns, c)
+		}, &c.Name, &c.Rank, &c.TableRank, &c.Descending, &c.Collation, &c.IsKey)
+	}, func(prefix string) error {
+		var err error
+		columns, err = legacyIndexColumns(db, prefix, indexName, includeAux)
+		return err
+	})
 	if err != nil {
 		return nil, fmt.Errorf("could not get columns for index %s: %s", indexName, err)
 	}
@@ -503,50 +620,128 @@ func (s *Schema) indexColumns(db *sql.DB, indexName string, includeAux bool) ([]
 	return columns, nil
 }
 
-func (s *Schema) masterTableNames(db *sql.DB, typ string) ([]string, error) {
+// A masterObject represents a row in an sqlite_master table.
+type masterObject struct {
+	Type  string
+	Name  string
+	Table string
+	SQL   sql.NullString // NULL for indexes created by SQLite (e.g. for UNIQUE constraints)
+}
 
-	tableName := "sqlite_master"
+func (o *masterObject) dest() []interface{} {
+	return []interface{}{&o.Type, &o.Name, &o.Table, &o.SQL}
+}
 
-	if s.name != "" {
-		if strings.ToLower(s.name) == "temp" {
-			tableName = "sqlite_temp_master"
-		} else {
-			// Unlike the other queries which use parameters, we insert
-			// the user-provided Schema name directly into the SQL here.
-			// So to protect against SQL injection, we first verify that
-			// a database with the given name exists.
-			ok, err := s.exists(db)
-			if err != nil {
-				return nil, fmt.Errorf("could not get %s names: %s", typ, err)
-			}
-			if !ok {
-				return nil, fmt.Errorf("could not get %s names: unknown database '%s'", typ, s.name)
-			}
-			tableName = s.name + "." + tableName
-		}
+// masterObjects returns the contents of this Schema's
+// sqlite_master table, sorted by type and name.
+func (s *Schema) masterObjects(db Querier) ([]masterObject, error) {
+
+	tableName, err := s.masterTable(db)
+	if err != nil {
+		return nil, err
 	}
 
-	q := fmt.Sprintf("SELECT name FROM %s WHERE type = ? ORDER BY name", tableName)
+	q := fmt.Sprintf("SELECT type, name, tbl_name, sql FROM %s ORDER BY type, name", tableName)
+
+	var obj masterObject
+	var objects []masterObject
 
-	names, err := queryStrings(db, q, typ)
+	err = scanRows(db, q, nil, func() {
+		objects = append(objects, obj)
+	}, obj.dest()...)
 	if err != nil {
-		return nil, fmt.Errorf("could not get %s names: %s", typ, err)
+		return nil, err
 	}
 
-	return names, nil
+	return objects, nil
 }
 
-func (s *Schema) exists(db *sql.DB) (bool, error) {
+// masterTable returns the name of the sqlite_master table
+// for this Schema, qualified by the schema name if necessary.
+func (s *Schema) masterTable(db Querier) (string, error) {
 
-	var count int
-	q := "SELECT COUNT(*) FROM pragma_database_list WHERE LOWER(name) = ?"
+	if s.name == "" {
+		return "sqlite_master", nil
+	}
 
-	err := db.QueryRow(q, sqlower(s.name)).Scan(&count)
+	if strings.ToLower(s.name) == "temp" {
+		return "sqlite_temp_master", nil
+	}
+
+	return s.qualify(db, "sqlite_master")
+}
+
+// qualify prefixes the given table name with the name of this
+// Schema.
+func (s *Schema) qualify(db Querier, tableName string) (string, error) {
+
+	if s.name == "" {
+		return tableName, nil
+	}
+
+	// Unlike the other queries which use parameters, we insert
+	// the user-provided Schema name directly into the SQL here.
+	// So to protect against SQL injection, we first verify that
+	// a database with the given name exists.
+	ok, err := s.exists(db)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", fmt.Errorf("unknown database '%s'", s.name)
+	}
+
+	return quoteIdent(s.name) + "." + tableName, nil
+}
+
+func (s *Schema) exists(db Querier) (bool, error) {
+
+	// The temp database is always available, even though it
+	// isn't listed until a temporary object is created.
+	if sqlower(s.name) == "temp" {
+		return true, nil
+	}
+
+	databases, err := databaseList(db)
 	if err != nil {
 		return false, err
 	}
 
-	return count > 0, nil
+	for _, d := range databases {
+		if sqlower(d.Name) == sqlower(s.name) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+type databaseRow struct {
+	Name string
+	File string
+}
+
+// databaseList returns the databases attached to the given
+// database connection.
+func databaseList(db Querier) ([]databaseRow, error) {
+
+	var r databaseRow
+	var rows []databaseRow
+
+	err := noSchema.queryPragma(db, func() error {
+		return scanRows(db, "SELECT name, file FROM pragma_database_list", nil, func() {
+			rows = append(rows, r)
+		}, &r.Name, &r.File)
+	}, func(string) error {
+		var err error
+		rows, err = legacyDatabaseRows(db)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return rows, nil
 }
 
 // sqlower replicates SQLite's LOWER function which converts
@@ -571,6 +766,11 @@ func toString(v interface{}) (string, bool) {
 	}
 }
 
+// quoteIdent returns s as a quoted SQL identifier.
+func quoteIdent(s string) string {
+	return `"` + strings.Replace(s, `"`, `""`, -1) + `"`
+}
+
 func placeholdersFor(vals []interface{}) string {
 	return strings.Join(strings.Split(strings.Repeat("?", len(vals)), ""), ", ")
 }
@@ -170,12 +170,12 @@ func testNames(t *testing.T, db *sql.DB) {
 
 	data := []struct {
 		Title string
-		Funcs []func(db *sql.DB) ([]string, error)
+		Funcs []func(db meta.Querier, opts ...meta.ListOption) ([]string, error)
 		Names []string
 	}{
 		{
 			Title: "Main Tables",
-			Funcs: []func(db *sql.DB) ([]string, error){
+			Funcs: []func(db meta.Querier, opts ...meta.ListOption) ([]string, error){
 				meta.TableNames,
 				meta.Main.TableNames,
 			},
@@ -187,7 +187,7 @@ func testNames(t *testing.T, db *sql.DB) {
 		},
 		{
 			Title: "Main Views",
-			Funcs: []func(db *sql.DB) ([]string, error){
+			Funcs: []func(db meta.Querier, opts ...meta.ListOption) ([]string, error){
 				meta.ViewNames,
 				meta.Main.ViewNames,
 			},
@@ -198,7 +198,7 @@ func testNames(t *testing.T, db *sql.DB) {
 		},
 		{
 			Title: "Main Triggers",
-			Funcs: []func(db *sql.DB) ([]string, error){
+			Funcs: []func(db meta.Querier, opts ...meta.ListOption) ([]string, error){
 				meta.TriggerNames,
 				meta.Main.TriggerNames,
 			},
@@ -208,7 +208,7 @@ func testNames(t *testing.T, db *sql.DB) {
 		},
 		{
 			Title: "Main Indexes",
-			Funcs: []func(db *sql.DB) ([]string, error){
+			Funcs: []func(db meta.Querier, opts ...meta.ListOption) ([]string, error){
 				meta.IndexNames,
 				meta.Main.IndexNames,
 			},
@@ -218,7 +218,7 @@ func testNames(t *testing.T, db *sql.DB) {
 		},
 		{
 			Title: "Temp Names",
-			Funcs: []func(db *sql.DB) ([]string, error){
+			Funcs: []func(db meta.Querier, opts ...meta.ListOption) ([]string, error){
 				meta.Temp.TableNames,
 				meta.Temp.ViewNames,
 				meta.Temp.TriggerNames,
@@ -425,7 +425,7 @@ func testColumns(t *testing.T, db *sql.DB) {
 		},
 	}
 
-	funcs := []func(*sql.DB, string) ([]meta.Column, error){
+	funcs := []func(meta.Querier, string) ([]meta.Column, error){
 		meta.Columns,
 		meta.Main.Columns,
 	}
@@ -682,7 +682,7 @@ func testForeignKeys(t *testing.T, db *sql.DB) {
 		},
 	}
 
-	funcs := []func(*sql.DB, string) ([]meta.ForeignKey, error){
+	funcs := []func(meta.Querier, string) ([]meta.ForeignKey, error){
 		meta.ForeignKeys,
 		meta.Main.ForeignKeys,
 	}
@@ -1017,7 +1017,7 @@ func testIndexes(t *testing.T, db *sql.DB) {
 		},
 	}
 
-	funcs := []func(*sql.DB, string) ([]meta.Index, error){
+	funcs := []func(meta.Querier, string) ([]meta.Index, error){
 		meta.Indexes,
 		meta.Main.Indexes,
 	}
@@ -1289,7 +1289,7 @@ func testIndexColumns(t *testing.T, db *sql.DB) {
 	funcData := []struct {
 		Aux  bool
 		Name string
-		Func func(*sql.DB, string) ([]meta.IndexColumn, error)
+		Func func(meta.Querier, string) ([]meta.IndexColumn, error)
 	}{
 		{
 			Name: "IndexColumns",
@@ -1344,7 +1344,7 @@ func testNamesBadSchema(t *testing.T, db *sql.DB) {
 
 	data := []struct {
 		Title  string
-		Func   func(*meta.Schema, *sql.DB) ([]string, error)
+		Func   func(*meta.Schema, meta.Querier, ...meta.ListOption) ([]string, error)
 		Object string
 	}{
 		{
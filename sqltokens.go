@@ -0,0 +1,246 @@
+package sqlitemeta
+
+import (
+	"strings"
+)
+
+// A tokenKind classifies an SQL token.
+type tokenKind uint
+
+const (
+	tokenWord   tokenKind = iota // An unquoted keyword or identifier.
+	tokenIdent                   // A quoted identifier, e.g. "name", [name] or `name`.
+	tokenString                  // A string literal, e.g. 'text'.
+	tokenNumber                  // A numeric literal.
+	tokenBlob                    // A blob literal, e.g. x'00ff'.
+	tokenVar                     // A parameter, e.g. ?, ?1, :name.
+	tokenPunct                   // An operator or punctuation mark.
+)
+
+// A token is a lexical unit of an SQL statement.
+type token struct {
+	kind tokenKind
+	text string // The raw text of the token.
+	pos  int    // The byte offset of the token in the statement.
+}
+
+// value returns the value of a token, with any quotes removed.
+func (t token) value() string {
+	switch t.kind {
+	case tokenIdent, tokenString:
+		return unquote(t.text)
+	default:
+		return t.text
+	}
+}
+
+// isWord reports whether the token is the given (unquoted)
+// keyword, ignoring case.
+func (t token) isWord(kw string) bool {
+	return t.kind == tokenWord && strings.EqualFold(t.text, kw)
+}
+
+// isName reports whether the token could be the name of a
+// table, column etc.
+func (t token) isName() bool {
+	return t.kind == tokenWord || t.kind == tokenIdent || t.kind == tokenString
+}
+
+func (t token) isPunct(p string) bool {
+	return t.kind == tokenPunct && t.text == p
+}
+
+// tokenize splits an SQL statement into tokens. Whitespace
+// and comments are discarded. Tokenizing is lenient: invalid
+// input results in a best-effort list of tokens, not an error.
+func tokenize(sql string) []token {
+
+	var tokens []token
+
+	for i := 0; i < len(sql); {
+
+		c := sql[i]
+		start := i
+
+		switch {
+		case isSpace(c):
+			i++
+			continue
+
+		case c == '-' && i+1 < len(sql) && sql[i+1] == '-':
+			for i < len(sql) && sql[i] != '\n' {
+				i++
+			}
+			continue
+
+		case c == '/' && i+1 < len(sql) && sql[i+1] == '*':
+			end := strings.Index(sql[i+2:], "*/")
+			if end < 0 {
+				i = len(sql)
+			} else {
+				i += end + 4
+			}
+			continue
+
+		case (c == 'x' || c == 'X') && i+1 < len(sql) && sql[i+1] == '\'':
+			i = scanQuoted(sql, i+1, '\'')
+			tokens = append(tokens, token{tokenBlob, sql[start:i], start})
+
+		case c == '\'':
+			i = scanQuoted(sql, i, '\'')
+			tokens = append(tokens, token{tokenString, sql[start:i], start})
+
+		case c == '"' || c == '`':
+			i = scanQuoted(sql, i, c)
+			tokens = append(tokens, token{tokenIdent, sql[start:i], start})
+
+		case c == '[':
+			end := strings.IndexByte(sql[i:], ']')
+			if end < 0 {
+				i = len(sql)
+			} else {
+				i += end + 1
+			}
+			tokens = append(tokens, token{tokenIdent, sql[start:i], start})
+
+		case isDigit(c) || c == '.' && i+1 < len(sql) && isDigit(sql[i+1]):
+			i = scanNumber(sql, i)
+			tokens = append(tokens, token{tokenNumber, sql[start:i], start})
+
+		case c == '?':
+			i++
+			for i < len(sql) && isDigit(sql[i]) {
+				i++
+			}
+			tokens = append(tokens, token{tokenVar, sql[start:i], start})
+
+		case (c == ':' || c == '@' || c == '$') && i+1 < len(sql) && isWordChar(sql[i+1]):
+			i++
+			for i < len(sql) && isWordChar(sql[i]) {
+				i++
+			}
+			tokens = append(tokens, token{tokenVar, sql[start:i], start})
+
+		case isWordChar(c):
+			for i < len(sql) && isWordChar(sql[i]) {
+				i++
+			}
+			tokens = append(tokens, token{tokenWord, sql[start:i], start})
+
+		default:
+			i += punctLen(sql[i:])
+			tokens = append(tokens, token{tokenPunct, sql[start:i], start})
+		}
+	}
+
+	return tokens
+}
+
+// scanQuoted returns the offset of the end of the quoted
+// string starting at sql[i]. A doubled quote character is
+// treated as an escaped quote.
+func scanQuoted(sql string, i int, quote byte) int {
+	for i++; i < len(sql); i++ {
+		if sql[i] == quote {
+			if i+1 < len(sql) && sql[i+1] == quote {
+				i++
+				continue
+			}
+			return i + 1
+		}
+	}
+	return len(sql)
+}
+
+func scanNumber(sql string, i int) int {
+
+	if strings.HasPrefix(sql[i:], "0x") || strings.HasPrefix(sql[i:], "0X") {
+		i += 2
+		for i < len(sql) && (isDigit(sql[i]) || strings.IndexByte("abcdefABCDEF", sql[i]) >= 0) {
+			i++
+		}
+		return i
+	}
+
+	for i < len(sql) && (isDigit(sql[i]) || sql[i] == '.') {
+		i++
+	}
+
+	if i < len(sql) && (sql[i] == 'e' || sql[i] == 'E') {
+		j := i + 1
+		if j < len(sql) && (sql[j] == '+' || sql[j] == '-') {
+			j++
+		}
+		if j < len(sql) && isDigit(sql[j]) {
+			i = j
+			for i < len(sql) && isDigit(sql[i]) {
+				i++
+			}
+		}
+	}
+
+	return i
+}
+
+// punctLen returns the length of the operator or punctuation
+// mark at the start of s.
+func punctLen(s string) int {
+	for _, op := range []string{"->>", "->", "<=", ">=", "<>", "!=", "==", "||", "<<", ">>"} {
+		if strings.HasPrefix(s, op) {
+			return len(op)
+		}
+	}
+	return 1
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isWordChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || isDigit(c) || c == '_' || c == '$' || c >= 0x80
+}
+
+// unquote removes the quotes from a quoted identifier or
+// string literal.
+func unquote(s string) string {
+
+	if len(s) < 2 {
+		return s
+	}
+
+	switch q := s[0]; q {
+	case '"', '\'', '`':
+		if s[len(s)-1] == q {
+			return strings.Replace(s[1:len(s)-1], string([]byte{q, q}), string(q), -1)
+		}
+	case '[':
+		if s[len(s)-1] == ']' {
+			return s[1 : len(s)-1]
+		}
+	}
+
+	return s
+}
+
+// isVirtualTableSQL reports whether sql is a CREATE VIRTUAL
+// TABLE statement.
+func isVirtualTableSQL(sql string) bool {
+	tokens := tokenize(sql)
+	return len(tokens) > 2 && tokens[0].isWord("CREATE") && tokens[1].isWord("VIRTUAL") && tokens[2].isWord("TABLE")
+}
+
+// hasWord reports whether the given tokens include the given
+// (unquoted) keyword.
+func hasWord(tokens []token, kw string) bool {
+	for _, t := range tokens {
+		if t.isWord(kw) {
+			return true
+		}
+	}
+	return false
+}
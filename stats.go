@@ -0,0 +1,484 @@
+package sqlitemeta
+
+import (
+	"context"
+	"database/sql"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// IndexStatistics holds the statistics gathered by the ANALYZE
+// command for an index.
+//
+// See https://sqlite.org/fileformat2.html#stat1tab for more on
+// SQLite's statistics tables.
+type IndexStatistics struct {
+	Table string
+	Index string
+
+	// RowCount is the approximate number of rows in the index.
+	RowCount int64
+
+	// Columns describes the selectivity of each column in the
+	// index (see IndexColumnStatistics).
+	Columns []IndexColumnStatistics
+
+	// Unordered is true if the query planner should not use
+	// the index for sorting or range queries.
+	Unordered bool
+
+	// NoSkipScan is true if the query planner should not use
+	// the index in a skip-scan.
+	NoSkipScan bool
+
+	// Samples holds the sample rows recorded in sqlite_stat4.
+	// Samples is empty unless SQLite was compiled with
+	// SQLITE_ENABLE_STAT4 (or STAT3) when ANALYZE was run.
+	Samples []IndexSample
+}
+
+// IndexColumnStatistics holds the statistics for a column in an
+// index.
+type IndexColumnStatistics struct {
+	IndexColumn
+
+	// AvgRows is the approximate number of rows in the index
+	// that have the same value in this column and all of the
+	// columns to its left. A value of 1 means that the leftmost
+	// columns up to and including this one are unique.
+	AvgRows int64
+}
+
+// An IndexSample is a row sampled from an index by ANALYZE.
+// Each of the slices has an entry for every column in the
+// index, including auxiliary columns such as the rowid.
+type IndexSample struct {
+	// Values holds the values of the index columns for the
+	// sampled row.
+	Values []interface{}
+
+	// Equal holds the approximate number of rows in the index
+	// whose leftmost N+1 columns are equal to those of the
+	// sample, where N is the position in the slice.
+	Equal []int64
+
+	// Less holds the approximate number of rows in the index
+	// whose leftmost N+1 columns are less than those of the
+	// sample.
+	Less []int64
+
+	// DistinctLess holds the approximate number of distinct
+	// values of the leftmost N+1 columns that are less than
+	// those of the sample.
+	DistinctLess []int64
+}
+
+// TableStatistics holds the statistics gathered by the ANALYZE
+// command for a table and its indexes.
+type TableStatistics struct {
+	Table string
+
+	// RowCount is the approximate number of rows in the table.
+	RowCount int64
+
+	// Indexes holds the statistics for the table's indexes,
+	// sorted by index name.
+	Indexes []IndexStatistics
+}
+
+// IndexStats returns the statistics for the given index in the
+// main database. Use the Schema.IndexStats method to query other
+// databases.
+//
+// If the index does not exist or has not been analyzed,
+// IndexStats returns nil.
+func IndexStats(db Querier, indexName string) (*IndexStatistics, error) {
+	return Main.IndexStats(db, indexName)
+}
+
+// IndexStats returns the statistics for the given index in this
+// Schema.
+//
+// If the index does not exist or has not been analyzed,
+// IndexStats returns nil.
+func (s *Schema) IndexStats(db Querier, indexName string) (*IndexStatistics, error) {
+
+	stats, err := s.indexStats(db, "LOWER(idx) = ?", sqlower(indexName))
+	if err != nil {
+		return nil, fmt.Errorf("could not get stats for index %s: %s", indexName, err)
+	}
+
+	if len(stats) == 0 {
+		return nil, nil
+	}
+
+	return &stats[0], nil
+}
+
+// TableStats returns the statistics for the given table in the
+// main database. Use the Schema.TableStats method to query other
+// databases.
+//
+// If the table does not exist or has not been analyzed,
+// TableStats returns nil.
+func TableStats(db Querier, tableName string) (*TableStatistics, error) {
+	return Main.TableStats(db, tableName)
+}
+
+// TableStats returns the statistics for the given table in this
+// Schema.
+//
+// If the table does not exist or has not been analyzed,
+// TableStats returns nil.
+func (s *Schema) TableStats(db Querier, tableName string) (*TableStatistics, error) {
+
+	rows, err := s.stat1Rows(db, "LOWER(tbl) = ?", sqlower(tableName))
+	if err != nil {
+		return nil, fmt.Errorf("could not get stats for table %s: %s", tableName, err)
+	}
+
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	ts := &TableStatistics{
+		Table: rows[0].Table,
+	}
+
+	// The table's row count is stored in a row with a NULL
+	// index if the table has no indexes. Otherwise we use the
+	// row count of the largest index.
+	for _, r := range rows {
+		if fields := strings.Fields(r.Stat); len(fields) > 0 {
+			if n, err := strconv.ParseInt(fields[0], 10, 64); err == nil && n > ts.RowCount {
+				ts.RowCount = n
+			}
+		}
+	}
+
+	ts.Indexes, err = s.indexStats(db, "LOWER(tbl) = ? AND idx IS NOT NULL", sqlower(tableName))
+	if err != nil {
+		return nil, fmt.Errorf("could not get stats for table %s: %s", tableName, err)
+	}
+
+	return ts, nil
+}
+
+type stat1Row struct {
+	Table string
+	Index sql.NullString
+	Stat  string
+}
+
+// stat1Rows returns the rows in this Schema's sqlite_stat1
+// table that match the given WHERE clause, sorted by index
+// name. It returns an empty slice if the table does not exist.
+func (s *Schema) stat1Rows(db Querier, where string, args ...interface{}) ([]stat1Row, error) {
+
+	ok, err := s.hasTable(db, "sqlite_stat1")
+	if err != nil || !ok {
+		return nil, err
+	}
+
+	tableName, err := s.qualify(db, "sqlite_stat1")
+	if err != nil {
+		return nil, err
+	}
+
+	q := fmt.Sprintf("SELECT tbl, idx, stat FROM %s WHERE %s ORDER BY idx", tableName, where)
+
+	var r stat1Row
+	var rows []stat1Row
+
+	err = scanRows(db, q, args, func() {
+		rows = append(rows, r)
+	}, &r.Table, &r.Index, &r.Stat)
+	if err != nil {
+		return nil, err
+	}
+
+	return rows, nil
+}
+
+func (s *Schema) indexStats(db Querier, where string, args ...interface{}) ([]IndexStatistics, error) {
+
+	rows, err := s.stat1Rows(db, where, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var stats []IndexStatistics
+
+	for _, r := range rows {
+
+		if !r.Index.Valid {
+			continue
+		}
+
+		st := parseStat1(r.Stat)
+		st.Table = r.Table
+		st.Index = r.Index.String
+
+		columns, err := s.IndexColumnsAux(db, st.Index)
+		if err != nil {
+			return nil, err
+		}
+
+		for i := range st.Columns {
+			if i < len(columns) {
+				st.Columns[i].IndexColumn = columns[i]
+			}
+		}
+
+		if st.Samples, err = s.indexSamples(db, st.Index); err != nil {
+			return nil, err
+		}
+
+		stats = append(stats, *st)
+	}
+
+	return stats, nil
+}
+
+// indexSamples returns the samples in this Schema's
+// sqlite_stat4 table for the given index, if any.
+func (s *Schema) indexSamples(db Querier, indexName string) ([]IndexSample, error) {
+
+	ok, err := s.hasTable(db, "sqlite_stat4")
+	if err != nil || !ok {
+		return nil, err
+	}
+
+	tableName, err := s.qualify(db, "sqlite_stat4")
+	if err != nil {
+		return nil, err
+	}
+
+	q := fmt.Sprintf("SELECT neq, nlt, ndlt, sample FROM %s WHERE LOWER(idx) = ? ORDER BY rowid", tableName)
+
+	type stat4Row struct {
+		Equal        string
+		Less         string
+		DistinctLess string
+		Sample       []byte
+	}
+
+	var r stat4Row
+	var rows []stat4Row
+
+	err = scanRows(db, q, []interface{}{sqlower(indexName)}, func() {
+		rows = append(rows, r)
+	}, &r.Equal, &r.Less, &r.DistinctLess, &r.Sample)
+	if err != nil {
+		return nil, err
+	}
+
+	var samples []IndexSample
+
+	for _, r := range rows {
+
+		var sample IndexSample
+
+		if sample.Equal, err = parseInts(r.Equal); err != nil {
+			return nil, err
+		}
+		if sample.Less, err = parseInts(r.Less); err != nil {
+			return nil, err
+		}
+		if sample.DistinctLess, err = parseInts(r.DistinctLess); err != nil {
+			return nil, err
+		}
+		if sample.Values, err = decodeRecord(r.Sample); err != nil {
+			return nil, err
+		}
+
+		samples = append(samples, sample)
+	}
+
+	return samples, nil
+}
+
+// hasTable reports whether this Schema contains a table with
+// the given name.
+func (s *Schema) hasTable(db Querier, tableName string) (bool, error) {
+
+	master, err := s.masterTable(db)
+	if err != nil {
+		return false, err
+	}
+
+	var count int
+	q := fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE type = 'table' AND LOWER(name) = ?", master)
+
+	err = db.QueryRowContext(context.Background(), q, sqlower(tableName)).Scan(&count)
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// parseStat1 parses the stat column of sqlite_stat1, e.g.
+// "1000 10 1 unordered".
+func parseStat1(stat string) *IndexStatistics {
+
+	st := &IndexStatistics{}
+
+	for i, field := range strings.Fields(stat) {
+
+		n, err := strconv.ParseInt(field, 10, 64)
+		if err != nil {
+			switch {
+			case field == "unordered":
+				st.Unordered = true
+			case field == "noskipscan":
+				st.NoSkipScan = true
+			}
+			// Ignore other keywords, e.g. sz=NNN.
+			continue
+		}
+
+		if i == 0 {
+			st.RowCount = n
+		} else {
+			st.Columns = append(st.Columns, IndexColumnStatistics{
+				AvgRows: n,
+			})
+		}
+	}
+
+	return st
+}
+
+// parseInts parses a space-separated list of integers.
+func parseInts(s string) ([]int64, error) {
+
+	var ints []int64
+
+	for _, field := range strings.Fields(s) {
+		n, err := strconv.ParseInt(field, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		ints = append(ints, n)
+	}
+
+	return ints, nil
+}
+
+var errBadRecord = errors.New("malformed record")
+
+// decodeRecord decodes a record in SQLite's record format,
+// as used in the sample column of sqlite_stat4. Text values
+// are assumed to be UTF-8.
+//
+// See https://sqlite.org/fileformat2.html#record_format for
+// details.
+func decodeRecord(b []byte) ([]interface{}, error) {
+
+	headerSize, n := readVarint(b)
+	if n == 0 || headerSize < uint64(n) || headerSize > uint64(len(b)) {
+		return nil, errBadRecord
+	}
+
+	header := b[n:headerSize]
+	body := b[headerSize:]
+
+	var values []interface{}
+
+	for len(header) > 0 {
+
+		typ, n := readVarint(header)
+		if n == 0 {
+			return nil, errBadRecord
+		}
+		header = header[n:]
+
+		size := serialTypeSize(typ)
+		if size > uint64(len(body)) {
+			return nil, errBadRecord
+		}
+
+		data := body[:size]
+		body = body[size:]
+
+		switch {
+		case typ == 0:
+			values = append(values, nil)
+		case typ >= 1 && typ <= 6:
+			values = append(values, readInt(data))
+		case typ == 7:
+			values = append(values, math.Float64frombits(binary.BigEndian.Uint64(data)))
+		case typ == 8:
+			values = append(values, int64(0))
+		case typ == 9:
+			values = append(values, int64(1))
+		case typ >= 12 && typ%2 == 0:
+			values = append(values, append([]byte(nil), data...))
+		case typ >= 13:
+			values = append(values, string(data))
+		default:
+			return nil, errBadRecord
+		}
+	}
+
+	return values, nil
+}
+
+// serialTypeSize returns the number of bytes occupied by a
+// value with the given serial type.
+func serialTypeSize(typ uint64) uint64 {
+	switch {
+	case typ >= 12:
+		return (typ - 12) / 2
+	case typ == 5:
+		return 6
+	case typ == 6, typ == 7:
+		return 8
+	case typ >= 1 && typ <= 4:
+		return typ
+	default:
+		return 0
+	}
+}
+
+// readInt decodes a big-endian twos-complement integer.
+func readInt(b []byte) int64 {
+
+	var n int64
+	if len(b) > 0 && b[0]&0x80 != 0 {
+		n = -1
+	}
+
+	for _, c := range b {
+		n = n<<8 | int64(c)
+	}
+
+	return n
+}
+
+// readVarint decodes one of SQLite's variable-length integers,
+// returning the value and the number of bytes read. It returns
+// 0 bytes read if b does not hold a complete varint.
+func readVarint(b []byte) (uint64, int) {
+
+	var v uint64
+
+	for i := 0; i < len(b) && i < 9; i++ {
+
+		if i == 8 {
+			return v<<8 | uint64(b[i]), 9
+		}
+
+		v = v<<7 | uint64(b[i]&0x7f)
+		if b[i]&0x80 == 0 {
+			return v, i + 1
+		}
+	}
+
+	return 0, 0
+}
@@ -0,0 +1,207 @@
+package sqlitemeta_test
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+
+	meta "github.com/deepilla/sqlitemeta"
+)
+
+func TestStats(t *testing.T) {
+	testWithDB(t, testStats)
+}
+
+func testStats(t *testing.T, db *sql.DB) {
+
+	exec(t, db, []string{
+		"DROP TABLE IF EXISTS events",
+		"DROP TABLE IF EXISTS logs",
+
+		`CREATE TABLE events (
+            id INTEGER PRIMARY KEY,
+            kind TEXT,
+            seq INTEGER
+        )`,
+		"CREATE INDEX idx_events_kind_seq ON events(kind, seq)",
+		"CREATE UNIQUE INDEX idx_events_seq ON events(seq)",
+		`CREATE TABLE logs (
+            msg TEXT
+        )`,
+	})
+
+	// Before ANALYZE, there are no stats.
+	is, err := meta.IndexStats(db, "idx_events_seq")
+	if err != nil {
+		t.Fatalf("IndexStats returned error %s", err)
+	}
+	if is != nil {
+		t.Errorf("Expected no index stats before ANALYZE, got %v", is)
+	}
+
+	exec(t, db, []string{
+		`WITH RECURSIVE n(i) AS (SELECT 0 UNION ALL SELECT i+1 FROM n WHERE i < 19)
+        INSERT INTO events (kind, seq)
+        SELECT CASE i % 2 WHEN 0 THEN 'even' ELSE 'odd' END, i FROM n`,
+		"INSERT INTO logs VALUES ('a'), ('b'), ('c')",
+		"ANALYZE",
+	})
+
+	ts, err := meta.TableStats(db, "events")
+	if err != nil {
+		t.Fatalf("TableStats returned error %s", err)
+	}
+	if ts == nil {
+		t.Fatalf("Expected stats for table events, got nil")
+	}
+
+	if ts.Table != "events" || ts.RowCount != 20 {
+		t.Errorf("Expected table events with 20 rows, got %s with %d rows", ts.Table, ts.RowCount)
+	}
+
+	type columnStats struct {
+		Name    sql.NullString
+		AvgRows int64
+	}
+
+	data := []struct {
+		Index    string
+		RowCount int64
+		Columns  []columnStats
+	}{
+		{
+			Index:    "idx_events_kind_seq",
+			RowCount: 20,
+			Columns: []columnStats{
+				{nullString("kind"), 10},
+				{nullString("seq"), 1},
+			},
+		},
+		{
+			Index:    "idx_events_seq",
+			RowCount: 20,
+			Columns: []columnStats{
+				{nullString("seq"), 1},
+			},
+		},
+	}
+
+	if len(ts.Indexes) != len(data) {
+		t.Fatalf("Expected stats for %d index(es), got %d", len(data), len(ts.Indexes))
+	}
+
+	for i, test := range data {
+
+		got := ts.Indexes[i]
+
+		if got.Index != test.Index || got.Table != "events" || got.RowCount != test.RowCount {
+			t.Errorf("Expected index %s on events with %d rows, got %s on %s with %d rows", test.Index, test.RowCount, got.Index, got.Table, got.RowCount)
+		}
+
+		var columns []columnStats
+		for _, c := range got.Columns {
+			columns = append(columns, columnStats{c.Name, c.AvgRows})
+		}
+
+		compareStructSlices(t, test.Index, "column", "column(s)", test.Columns, columns)
+
+		// Samples are only present if SQLite was compiled with
+		// stat4 support.
+		for _, sample := range got.Samples {
+			if len(sample.Values) != len(sample.Equal) || len(sample.Values) != len(sample.Less) || len(sample.Values) != len(sample.DistinctLess) {
+				t.Errorf("%s: Expected sample fields of equal length, got %v", test.Index, sample)
+			}
+			if s, ok := sample.Values[0].(string); test.Index == "idx_events_kind_seq" && (!ok || s != "even" && s != "odd") {
+				t.Errorf("%s: Expected sample to begin with a kind, got %v", test.Index, sample.Values)
+			}
+		}
+	}
+
+	is, err = meta.IndexStats(db, "IDX_EVENTS_SEQ")
+	if err != nil {
+		t.Fatalf("IndexStats returned error %s", err)
+	}
+	if is == nil || is.Index != "idx_events_seq" || is.RowCount != 20 {
+		t.Errorf("Expected stats for index idx_events_seq, got %v", is)
+	}
+
+	ts, err = meta.TableStats(db, "logs")
+	if err != nil {
+		t.Fatalf("TableStats returned error %s", err)
+	}
+	if ts == nil || ts.RowCount != 3 || len(ts.Indexes) != 0 {
+		t.Errorf("Expected 3 rows and no indexes for table logs, got %v", ts)
+	}
+
+	ts, err = meta.TableStats(db, "xxxxx")
+	if err != nil {
+		t.Fatalf("TableStats returned error %s", err)
+	}
+	if ts != nil {
+		t.Errorf("Expected no stats for unknown table, got %v", ts)
+	}
+
+	_, err = meta.DB("xxxxx").TableStats(db, "events")
+	if exp := "could not get stats for table events: unknown database 'xxxxx'"; err == nil || err.Error() != exp {
+		t.Errorf("Expected error %q, got %v", exp, err)
+	}
+}
+
+func TestDecodeRecord(t *testing.T) {
+
+	data := []struct {
+		Name   string
+		Record []byte
+		Values []interface{}
+		Err    error
+	}{
+		{
+			Name: "Values",
+			// Header: size 5, int8, NULL, 2-byte text, 1-byte blob.
+			Record: []byte{0x05, 0x01, 0x00, 0x11, 0x0e, 0x2a, 'a', 'b', 0xff},
+			Values: []interface{}{int64(42), nil, "ab", []byte{0xff}},
+		},
+		{
+			Name:   "Empty header",
+			Record: []byte{0x01},
+		},
+		{
+			Name:   "No data",
+			Record: []byte{},
+			Err:    meta.ErrBadRecord,
+		},
+		{
+			Name:   "Header size of zero",
+			Record: []byte{0x00, 0x01, 0x2a},
+			Err:    meta.ErrBadRecord,
+		},
+		{
+			Name:   "Header size too large",
+			Record: []byte{0x05, 0x01, 0x2a},
+			Err:    meta.ErrBadRecord,
+		},
+		{
+			Name:   "Body too short",
+			Record: []byte{0x02, 0x02, 0x2a},
+			Err:    meta.ErrBadRecord,
+		},
+		{
+			Name:   "Reserved serial type",
+			Record: []byte{0x02, 0x0a},
+			Err:    meta.ErrBadRecord,
+		},
+	}
+
+	for _, test := range data {
+
+		values, err := meta.DecodeRecord(test.Record)
+		if err != test.Err {
+			t.Errorf("%s: Expected error %v, got %v", test.Name, test.Err, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(values, test.Values) {
+			t.Errorf("%s: Expected values %v, got %v", test.Name, test.Values, values)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package sqlitemeta
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// A CapabilityError is returned when a feature is not supported
+// by the SQLite library in use, e.g. because it was not enabled
+// at compile time.
+type CapabilityError struct {
+	Feature string // The missing feature, e.g. "dbstat".
+	Err     error  // The error returned by SQLite.
+}
+
+func (e *CapabilityError) Error() string {
+	return fmt.Sprintf("%s is not available: %s", e.Feature, e.Err)
+}
+
+// ObjectStorage describes the disk space used by a table or
+// index.
+type ObjectStorage struct {
+	Name  string
+	Type  string // "table" or "index".
+	Table string // The table that an index belongs to, or the table itself.
+
+	Pages         int64 // Total number of pages.
+	LeafPages     int64
+	InternalPages int64
+	OverflowPages int64
+	Cells         int64 // Number of cells on leaf and internal pages.
+
+	TotalBytes   int64 // Total size of the object's pages.
+	PayloadBytes int64 // Bytes used to store keys and data.
+	UnusedBytes  int64 // Unused bytes on the object's pages.
+
+	// Fragmentation is the fraction of pages (between 0 and 1)
+	// that are not stored immediately after the previous page
+	// of the object.
+	Fragmentation float64
+}
+
+// StorageUsage returns the disk space used by each table and
+// index in the main database, sorted by name. Use the
+// Schema.StorageUsage method to query other databases.
+func StorageUsage(db Querier) ([]ObjectStorage, error) {
+	return Main.StorageUsage(db)
+}
+
+// StorageUsage returns the disk space used by each table and
+// index in this Schema, sorted by name. The sqlite_master table
+// is included.
+//
+// StorageUsage requires the dbstat virtual table which is only
+// available if SQLite was compiled with SQLITE_ENABLE_DBSTAT_VTAB.
+// If it is not available, StorageUsage returns a
+// *CapabilityError.
+func (s *Schema) StorageUsage(db Querier) ([]ObjectStorage, error) {
+
+	if s.name == "" {
+		return Main.StorageUsage(db)
+	}
+
+	objects, err := s.masterObjects(db)
+	if err != nil {
+		return nil, fmt.Errorf("could not get storage usage: %s", err)
+	}
+
+	// Rows are returned in b-tree traversal order, so that the
+	// pages of each object are listed in sequence.
+	q :=
+		`SELECT
+			name,
+			pageno,
+			pagetype,
+			ncell,
+			payload,
+			unused,
+			pgsize
+		FROM
+			dbstat(?)`
+
+	type dbstatRow struct {
+		Name     string
+		PageNo   int64
+		PageType string
+		Cells    int64
+		Payload  int64
+		Unused   int64
+		PageSize int64
+	}
+
+	var p dbstatRow
+	var pages []dbstatRow
+
+	err = scanRows(db, q, []interface{}{s.name}, func() {
+		pages = append(pages, p)
+	}, &p.Name, &p.PageNo, &p.PageType, &p.Cells, &p.Payload, &p.Unused, &p.PageSize)
+	if err != nil {
+		if strings.Contains(err.Error(), "no such table: dbstat") {
+			return nil, &CapabilityError{
+				Feature: "dbstat",
+				Err:     err,
+			}
+		}
+		return nil, fmt.Errorf("could not get storage usage: %s", err)
+	}
+
+	byName := map[string]int{}
+	lastPage := map[string]int64{}
+	fragmented := map[string]int64{}
+
+	var usage []ObjectStorage
+
+	for _, p := range pages {
+
+		key := sqlower(p.Name)
+
+		i, ok := byName[key]
+		if !ok {
+			i = len(usage)
+			byName[key] = i
+			usage = append(usage, ObjectStorage{
+				Name:  p.Name,
+				Type:  "table",
+				Table: p.Name,
+			})
+		} else if p.PageNo != lastPage[key]+1 {
+			fragmented[key]++
+		}
+
+		lastPage[key] = p.PageNo
+
+		st := &usage[i]
+
+		st.Pages++
+		switch p.PageType {
+		case "leaf":
+			st.LeafPages++
+		case "internal":
+			st.InternalPages++
+		case "overflow":
+			st.OverflowPages++
+		}
+
+		st.Cells += p.Cells
+		st.TotalBytes += p.PageSize
+		st.PayloadBytes += p.Payload
+		st.UnusedBytes += p.Unused
+	}
+
+	for i := range usage {
+
+		st := &usage[i]
+
+		if st.Pages > 1 {
+			st.Fragmentation = float64(fragmented[sqlower(st.Name)]) / float64(st.Pages-1)
+		}
+
+		for _, obj := range objects {
+			if sqlower(obj.Name) == sqlower(st.Name) {
+				st.Type = obj.Type
+				st.Table = obj.Table
+				break
+			}
+		}
+	}
+
+	sort.Sort(byStorageName(usage))
+
+	return usage, nil
+}
+
+type byStorageName []ObjectStorage
+
+func (s byStorageName) Len() int           { return len(s) }
+func (s byStorageName) Less(i, j int) bool { return sqlower(s[i].Name) < sqlower(s[j].Name) }
+func (s byStorageName) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
@@ -0,0 +1,79 @@
+package sqlitemeta_test
+
+import (
+	"database/sql"
+	"testing"
+
+	meta "github.com/deepilla/sqlitemeta"
+)
+
+func TestStorageUsage(t *testing.T) {
+	testWithDB(t, testStorageUsage)
+}
+
+func testStorageUsage(t *testing.T, db *sql.DB) {
+
+	exec(t, db, []string{
+		"DROP TABLE IF EXISTS docs",
+
+		`CREATE TABLE docs (
+            id INTEGER PRIMARY KEY,
+            title TEXT,
+            body TEXT
+        )`,
+		"CREATE INDEX idx_docs_title ON docs(title)",
+		`WITH RECURSIVE n(i) AS (SELECT 0 UNION ALL SELECT i+1 FROM n WHERE i < 99)
+        INSERT INTO docs (title, body)
+        SELECT 'Document ' || i, CASE i WHEN 0 THEN zeroblob(20000) ELSE 'text' END FROM n`,
+	})
+
+	usage, err := meta.StorageUsage(db)
+	if err != nil {
+		if e, ok := err.(*meta.CapabilityError); ok {
+			if e.Feature != "dbstat" {
+				t.Errorf("Expected a CapabilityError for dbstat, got %s", e.Feature)
+			}
+			t.Skip(err)
+		}
+		t.Fatalf("StorageUsage returned error %s", err)
+	}
+
+	byName := map[string]meta.ObjectStorage{}
+	for _, st := range usage {
+		byName[st.Name] = st
+	}
+
+	docs, ok := byName["docs"]
+	if !ok {
+		t.Fatalf("Expected storage usage for table docs, got %v", usage)
+	}
+
+	if docs.Type != "table" || docs.Table != "docs" {
+		t.Errorf("Expected docs to be a table, got %s on %s", docs.Type, docs.Table)
+	}
+	if docs.OverflowPages == 0 {
+		t.Errorf("Expected docs to have overflow pages")
+	}
+	if docs.Pages != docs.LeafPages+docs.InternalPages+docs.OverflowPages {
+		t.Errorf("Expected %d pages, got %d", docs.LeafPages+docs.InternalPages+docs.OverflowPages, docs.Pages)
+	}
+	if docs.PayloadBytes < 20000 || docs.PayloadBytes+docs.UnusedBytes > docs.TotalBytes {
+		t.Errorf("Unexpected payload of %d bytes (%d unused, %d total)", docs.PayloadBytes, docs.UnusedBytes, docs.TotalBytes)
+	}
+	if docs.Cells < 100 {
+		t.Errorf("Expected at least 100 cells, got %d", docs.Cells)
+	}
+	if docs.Fragmentation < 0 || docs.Fragmentation > 1 {
+		t.Errorf("Expected fragmentation between 0 and 1, got %f", docs.Fragmentation)
+	}
+
+	idx, ok := byName["idx_docs_title"]
+	if !ok || idx.Type != "index" || idx.Table != "docs" || idx.Pages == 0 {
+		t.Errorf("Expected storage usage for index idx_docs_title on docs, got %v", idx)
+	}
+
+	_, err = meta.DB("xxxxx").StorageUsage(db)
+	if exp := "could not get storage usage: unknown database 'xxxxx'"; err == nil || err.Error() != exp {
+		t.Errorf("Expected error %q, got %v", exp, err)
+	}
+}
@@ -0,0 +1,123 @@
+package sqlitemeta
+
+import (
+	"fmt"
+	"sort"
+)
+
+// shadowTableSuffixes lists the suffixes of the shadow tables
+// used by each virtual table module. A virtual table's shadow
+// tables are named after the virtual table, e.g. "docs_data".
+var shadowTableSuffixes = map[string][]string{
+	"fts3":      {"content", "segments", "segdir", "docsize", "stat"},
+	"fts4":      {"content", "segments", "segdir", "docsize", "stat"},
+	"fts5":      {"data", "idx", "content", "docsize", "config"},
+	"rtree":     {"node", "parent", "rowid"},
+	"rtree_i32": {"node", "parent", "rowid"},
+}
+
+// ShadowTableNames returns the names of the shadow tables in
+// the main database. Use the Schema.ShadowTableNames method to
+// query other databases.
+func ShadowTableNames(db Querier) ([]string, error) {
+	return noSchema.ShadowTableNames(db)
+}
+
+// ShadowTableNames returns the names of the shadow tables in
+// this Schema, sorted alphabetically.
+//
+// Shadow tables are ordinary tables that virtual tables (such
+// as full-text search and R*Tree tables) use to store their
+// data. They are internal to the virtual table and should not
+// be modified directly. Shadow tables are recognised for the
+// FTS3, FTS4, FTS5 and R*Tree modules.
+func (s *Schema) ShadowTableNames(db Querier) ([]string, error) {
+
+	objects, err := s.masterObjects(db)
+	if err != nil {
+		return nil, fmt.Errorf("could not get shadow table names: %s", err)
+	}
+
+	var names []string
+
+	for _, obj := range objects {
+		if obj.Type == "table" && obj.SQL.Valid {
+			module, _ := virtualTableArgs(obj.SQL.String)
+			names = append(names, shadowTables(obj.Name, sqlower(module), objects)...)
+		}
+	}
+
+	sort.Strings(names)
+
+	return names, nil
+}
+
+// shadowTables returns the names of the shadow tables that
+// exist for the given virtual table, in the order listed in
+// shadowTableSuffixes.
+func shadowTables(name, module string, objects []masterObject) []string {
+
+	var names []string
+
+	for _, suffix := range shadowTableSuffixes[module] {
+		for _, obj := range objects {
+			if obj.Type == "table" && sqlower(obj.Name) == sqlower(name+"_"+suffix) {
+				names = append(names, obj.Name)
+				break
+			}
+		}
+	}
+
+	return names
+}
+
+// virtualTableArgs returns the module name and arguments from a
+// CREATE VIRTUAL TABLE statement. Each argument is returned as a
+// list of tokens.
+func virtualTableArgs(sql string) (string, [][]token) {
+
+	if !isVirtualTableSQL(sql) {
+		return "", nil
+	}
+
+	tokens := tokenize(sql)
+
+	i := 0
+	for i < len(tokens) && !tokens[i].isWord("USING") {
+		i++
+	}
+	if i+1 >= len(tokens) {
+		return "", nil
+	}
+
+	module := tokens[i+1].value()
+
+	start := i + 2
+	if start >= len(tokens) || !tokens[start].isPunct("(") {
+		return module, nil
+	}
+
+	end := closingParen(tokens, start)
+
+	var args [][]token
+	var arg []token
+	depth := 0
+
+	for _, t := range tokens[start+1 : end] {
+		switch {
+		case t.isPunct("("):
+			depth++
+		case t.isPunct(")"):
+			depth--
+		case t.isPunct(",") && depth == 0:
+			args = append(args, arg)
+			arg = nil
+			continue
+		}
+		arg = append(arg, t)
+	}
+
+	args = append(args, arg)
+
+	return module, args
+}
@@ -0,0 +1,321 @@
+package sqlitemeta
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// A ChangeType describes how a schema object has changed.
+type ChangeType uint
+
+const (
+	// ChangeAdded indicates that an object was created.
+	ChangeAdded ChangeType = iota
+
+	// ChangeDropped indicates that an object was dropped.
+	ChangeDropped
+
+	// ChangeAltered indicates that the SQL that defines an
+	// object has changed, e.g. following an ALTER TABLE.
+	ChangeAltered
+)
+
+// String returns the name of a ChangeType, e.g. "added".
+func (t ChangeType) String() string {
+	switch t {
+	case ChangeAdded:
+		return "added"
+	case ChangeDropped:
+		return "dropped"
+	case ChangeAltered:
+		return "altered"
+	default:
+		return fmt.Sprintf("ChangeType(%d)", uint(t))
+	}
+}
+
+// A SchemaChange describes a change to a table, index, view or
+// trigger.
+type SchemaChange struct {
+	Type       ChangeType
+	ObjectType string // "table", "index", "view" or "trigger".
+	Name       string
+	Table      string // The table that the object belongs to.
+	OldSQL     string // Empty if the object was added.
+	NewSQL     string // Empty if the object was dropped.
+}
+
+// A SchemaEvent reports the changes to a database's schema
+// detected by Watch.
+type SchemaEvent struct {
+	Schema  string
+	Version int64 // The new schema version.
+	Changes []SchemaChange
+
+	// Err is non-nil if Watch was unable to check for changes.
+	// In this case, the other fields are empty.
+	Err error
+}
+
+// Watch polls the given database connection for schema changes
+// at the given interval, and sends an event to the returned
+// channel for each database whose schema has changed. This is
+// useful for detecting changes made by other processes.
+//
+// Temp and attached databases are per-connection. To watch
+// them, pass a *sql.Conn rather than a *sql.DB. Otherwise each
+// poll may use a different pooled connection and report the
+// databases as repeatedly attached and detached.
+//
+// Changes are detected by comparing PRAGMA schema_version for
+// each attached database (other than temp) with its previous
+// value. When the version changes, the contents of the
+// database's sqlite_master table are compared with the previous
+// contents to produce a list of changes. Databases that are
+// attached or detached are reported as having all of their
+// objects added or dropped.
+//
+// Errors are sent as events with a non-nil Err field. Watch
+// continues to poll after an error.
+//
+// Intervals shorter than 10 milliseconds (including zero and
+// negative intervals) are rounded up to 10 milliseconds.
+//
+// Watching stops and the channel is closed when ctx is
+// cancelled.
+func Watch(ctx context.Context, db Querier, interval time.Duration) <-chan SchemaEvent {
+
+	if interval < minWatchInterval {
+		interval = minWatchInterval
+	}
+
+	ch := make(chan SchemaEvent)
+	w := &watcher{
+		db:        db,
+		snapshots: map[string]*schemaSnapshot{},
+	}
+
+	// Take the initial snapshots before returning so that any
+	// subsequent changes are reported.
+	_, err := w.poll()
+
+	go func() {
+
+		defer close(ch)
+
+		if err != nil && !w.send(ctx, ch, SchemaEvent{Err: err}) {
+			return
+		}
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			events, err := w.poll()
+			if err != nil {
+				events = []SchemaEvent{{Err: err}}
+			}
+
+			for _, e := range events {
+				if !w.send(ctx, ch, e) {
+					return
+				}
+			}
+		}
+	}()
+
+	return ch
+}
+
+// minWatchInterval is the shortest interval that Watch polls at.
+const minWatchInterval = 10 * time.Millisecond
+
+type watcher struct {
+	db          Querier
+	snapshots   map[string]*schemaSnapshot // Keyed by lowercase schema name.
+	initialized bool
+}
+
+type schemaSnapshot struct {
+	name    string
+	version int64
+	objects []masterObject
+}
+
+// send sends an event unless ctx is cancelled first. It reports
+// whether the event was sent.
+func (w *watcher) send(ctx context.Context, ch chan<- SchemaEvent, e SchemaEvent) bool {
+	select {
+	case ch <- e:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// poll takes new snapshots of any databases whose schemas have
+// changed and returns the changes, sorted by schema name. The
+// snapshots are only updated if every database is checked
+// successfully, so changes are not lost if an error occurs.
+func (w *watcher) poll() ([]SchemaEvent, error) {
+
+	databases, err := databaseList(w.db)
+	if err != nil {
+		return nil, fmt.Errorf("could not check for schema changes: %s", err)
+	}
+
+	var events []SchemaEvent
+	snapshots := map[string]*schemaSnapshot{}
+
+	for _, d := range databases {
+
+		key := sqlower(d.Name)
+		if key == "temp" {
+			continue
+		}
+
+		version, err := schemaVersion(w.db, d.Name)
+		if err != nil {
+			return nil, fmt.Errorf("could not check for schema changes in %s: %s", d.Name, err)
+		}
+
+		prev := w.snapshots[key]
+		if prev != nil && prev.version == version {
+			snapshots[key] = prev
+			continue
+		}
+
+		objects, err := DB(d.Name).masterObjects(w.db)
+		if err != nil {
+			return nil, fmt.Errorf("could not check for schema changes in %s: %s", d.Name, err)
+		}
+
+		snapshots[key] = &schemaSnapshot{
+			name:    d.Name,
+			version: version,
+			objects: objects,
+		}
+
+		var old []masterObject
+		if prev != nil {
+			old = prev.objects
+		}
+
+		if changes := diffObjects(old, objects); len(changes) > 0 {
+			events = append(events, SchemaEvent{
+				Schema:  d.Name,
+				Version: version,
+				Changes: changes,
+			})
+		}
+	}
+
+	for key, snap := range w.snapshots {
+		if snapshots[key] == nil {
+			events = append(events, SchemaEvent{
+				Schema:  snap.name,
+				Changes: diffObjects(snap.objects, nil),
+			})
+		}
+	}
+
+	w.snapshots = snapshots
+
+	// The first successful poll establishes a baseline.
+	if !w.initialized {
+		w.initialized = true
+		return nil, nil
+	}
+
+	sort.Sort(bySchemaName(events))
+
+	return events, nil
+}
+
+type bySchemaName []SchemaEvent
+
+func (e bySchemaName) Len() int           { return len(e) }
+func (e bySchemaName) Less(i, j int) bool { return sqlower(e[i].Schema) < sqlower(e[j].Schema) }
+func (e bySchemaName) Swap(i, j int)      { e[i], e[j] = e[j], e[i] }
+
+// diffObjects compares two snapshots of an sqlite_master table
+// and returns the differences, sorted by object type and name.
+func diffObjects(old, new []masterObject) []SchemaChange {
+
+	type objectKey struct {
+		typ  string
+		name string
+	}
+
+	before := map[objectKey]masterObject{}
+	for _, obj := range old {
+		before[objectKey{obj.Type, sqlower(obj.Name)}] = obj
+	}
+
+	after := map[objectKey]masterObject{}
+	for _, obj := range new {
+		after[objectKey{obj.Type, sqlower(obj.Name)}] = obj
+	}
+
+	var changes []SchemaChange
+
+	for _, obj := range new {
+
+		prev, ok := before[objectKey{obj.Type, sqlower(obj.Name)}]
+
+		switch {
+		case !ok:
+			changes = append(changes, SchemaChange{
+				Type:       ChangeAdded,
+				ObjectType: obj.Type,
+				Name:       obj.Name,
+				Table:      obj.Table,
+				NewSQL:     obj.SQL.String,
+			})
+		case prev.SQL != obj.SQL:
+			changes = append(changes, SchemaChange{
+				Type:       ChangeAltered,
+				ObjectType: obj.Type,
+				Name:       obj.Name,
+				Table:      obj.Table,
+				OldSQL:     prev.SQL.String,
+				NewSQL:     obj.SQL.String,
+			})
+		}
+	}
+
+	for _, obj := range old {
+		if _, ok := after[objectKey{obj.Type, sqlower(obj.Name)}]; !ok {
+			changes = append(changes, SchemaChange{
+				Type:       ChangeDropped,
+				ObjectType: obj.Type,
+				Name:       obj.Name,
+				Table:      obj.Table,
+				OldSQL:     obj.SQL.String,
+			})
+		}
+	}
+
+	sort.Sort(byObjectName(changes))
+
+	return changes
+}
+
+type byObjectName []SchemaChange
+
+func (c byObjectName) Len() int      { return len(c) }
+func (c byObjectName) Swap(i, j int) { c[i], c[j] = c[j], c[i] }
+func (c byObjectName) Less(i, j int) bool {
+	if c[i].ObjectType != c[j].ObjectType {
+		return c[i].ObjectType < c[j].ObjectType
+	}
+	return sqlower(c[i].Name) < sqlower(c[j].Name)
+}
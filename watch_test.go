@@ -0,0 +1,293 @@
+package sqlitemeta_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	meta "github.com/deepilla/sqlitemeta"
+)
+
+func TestWatch(t *testing.T) {
+	testWithDB(t, testWatch)
+}
+
+func testWatch(t *testing.T, db *sql.DB) {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Temp and attached databases are per-connection, so
+	// the watcher must use the same connection as we do.
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatalf("Could not get connection: %s", err)
+	}
+	defer conn.Close()
+
+	for _, q := range []string{
+		"DROP TABLE IF EXISTS users",
+		"CREATE TABLE users (id INTEGER PRIMARY KEY)",
+	} {
+		if _, err := conn.ExecContext(ctx, q); err != nil {
+			t.Fatalf("Exec %q returned error %s", q, err)
+		}
+	}
+
+	events := meta.Watch(ctx, conn, 5*time.Millisecond)
+
+	next := func(title string) meta.SchemaEvent {
+		select {
+		case e := <-events:
+			if e.Err != nil {
+				t.Fatalf("%s: Watch returned error %s", title, e.Err)
+			}
+			return e
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%s: Timed out waiting for schema event", title)
+		}
+		return meta.SchemaEvent{}
+	}
+
+	type change struct {
+		Type       meta.ChangeType
+		ObjectType string
+		Name       string
+		Table      string
+	}
+
+	data := []struct {
+		Title   string
+		SQL     []string
+		Schema  string
+		Changes []change
+	}{
+		{
+			Title: "Add",
+			SQL: []string{
+				"CREATE TABLE posts (id INTEGER PRIMARY KEY, user_id INTEGER)",
+				"CREATE INDEX idx_posts_user_id ON posts(user_id)",
+			},
+			Schema: "main",
+			Changes: []change{
+				{meta.ChangeAdded, "index", "idx_posts_user_id", "posts"},
+				{meta.ChangeAdded, "table", "posts", "posts"},
+			},
+		},
+		{
+			Title: "Alter and Drop",
+			SQL: []string{
+				"ALTER TABLE users ADD COLUMN name TEXT",
+				"DROP INDEX idx_posts_user_id",
+			},
+			Schema: "main",
+			Changes: []change{
+				{meta.ChangeDropped, "index", "idx_posts_user_id", "posts"},
+				{meta.ChangeAltered, "table", "users", "users"},
+			},
+		},
+		{
+			Title: "Attach",
+			SQL: []string{
+				"ATTACH DATABASE ':memory:' AS aux",
+				"CREATE TABLE aux.logs (msg TEXT)",
+			},
+			Schema: "aux",
+			Changes: []change{
+				{meta.ChangeAdded, "table", "logs", "logs"},
+			},
+		},
+		{
+			Title: "Temp",
+			SQL: []string{
+				"CREATE TEMP TABLE scratch (x)",
+				"CREATE VIEW aux.messages AS SELECT msg FROM logs",
+			},
+			Schema: "aux",
+			Changes: []change{
+				{meta.ChangeAdded, "view", "messages", "messages"},
+			},
+		},
+		{
+			Title: "Detach",
+			SQL: []string{
+				"DETACH DATABASE aux",
+			},
+			Schema: "aux",
+			Changes: []change{
+				{meta.ChangeDropped, "table", "logs", "logs"},
+				{meta.ChangeDropped, "view", "messages", "messages"},
+			},
+		},
+	}
+
+	for _, test := range data {
+
+		// Make the changes in a single call so that the watcher,
+		// which shares our connection, can't poll between them.
+		q := strings.Join(test.SQL, "; ")
+		if _, err := conn.ExecContext(ctx, q); err != nil {
+			t.Fatalf("%s: Exec %q returned error %s", test.Title, q, err)
+		}
+
+		e := next(test.Title)
+
+		if e.Schema != test.Schema {
+			t.Errorf("%s: Expected schema %s, got %s", test.Title, test.Schema, e.Schema)
+		}
+
+		var got []change
+		for _, c := range e.Changes {
+			got = append(got, change{c.Type, c.ObjectType, c.Name, c.Table})
+		}
+
+		compareStructSlices(t, test.Title, "change", "change(s)", test.Changes, got)
+	}
+
+	cancel()
+
+	for range events {
+		// Drain the channel until Watch closes it.
+	}
+}
+
+func TestWatchMinInterval(t *testing.T) {
+	testWithDB(t, testWatchMinInterval)
+}
+
+func testWatchMinInterval(t *testing.T, db *sql.DB) {
+
+	conn, err := db.Conn(context.Background())
+	if err != nil {
+		t.Fatalf("Could not get connection: %s", err)
+	}
+	defer conn.Close()
+
+	for i, interval := range []time.Duration{0, -time.Second} {
+
+		ctx, cancel := context.WithCancel(context.Background())
+
+		// A non-positive interval must not panic.
+		events := meta.Watch(ctx, conn, interval)
+
+		q := fmt.Sprintf("CREATE TABLE test%d (id INTEGER PRIMARY KEY)", i)
+		if _, err := conn.ExecContext(ctx, q); err != nil {
+			t.Fatalf("Exec %q returned error %s", q, err)
+		}
+
+		select {
+		case e := <-events:
+			if e.Err != nil {
+				t.Fatalf("Watch(%s) returned error %s", interval, e.Err)
+			}
+			if len(e.Changes) == 0 {
+				t.Errorf("Watch(%s): Expected changes, got none", interval)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Watch(%s): Timed out waiting for schema event", interval)
+		}
+
+		cancel()
+
+		for range events {
+			// Drain the channel until Watch closes it.
+		}
+	}
+}
+
+func TestWatchError(t *testing.T) {
+	testWithDB(t, testWatchError)
+}
+
+func testWatchError(t *testing.T, db *sql.DB) {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatalf("Could not get connection: %s", err)
+	}
+	defer conn.Close()
+
+	f, err := ioutil.TempFile("", "sqlitemeta-test")
+	if err != nil {
+		t.Fatalf("Could not create file: %s", err)
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+
+	for _, q := range []string{
+		"ATTACH DATABASE '" + f.Name() + "' AS aux",
+		"CREATE TABLE aux.logs (msg TEXT)",
+	} {
+		if _, err := conn.ExecContext(ctx, q); err != nil {
+			t.Fatalf("Exec %q returned error %s", q, err)
+		}
+	}
+
+	events := meta.Watch(ctx, conn, 5*time.Millisecond)
+
+	// Corrupt the attached database so that polling fails
+	// after the main database has been checked.
+	contents, err := ioutil.ReadFile(f.Name())
+	if err != nil {
+		t.Fatalf("Could not read file: %s", err)
+	}
+
+	garbage := append([]byte{}, contents...)
+	for i := 0; i < 100; i++ {
+		garbage[i] = 0xff
+	}
+
+	if err := ioutil.WriteFile(f.Name(), garbage, 0600); err != nil {
+		t.Fatalf("Could not write file: %s", err)
+	}
+
+	timeout := time.After(5 * time.Second)
+
+	next := func() meta.SchemaEvent {
+		select {
+		case e := <-events:
+			return e
+		case <-timeout:
+			t.Fatalf("Timed out waiting for schema event")
+		}
+		return meta.SchemaEvent{}
+	}
+
+	if e := next(); e.Err == nil {
+		t.Fatalf("Expected an error event, got %+v", e)
+	}
+
+	q := "CREATE TABLE users (id INTEGER PRIMARY KEY)"
+	if _, err := conn.ExecContext(ctx, q); err != nil {
+		t.Fatalf("Exec %q returned error %s", q, err)
+	}
+
+	// Wait for a poll that has seen the new table.
+	next()
+	next()
+
+	if err := ioutil.WriteFile(f.Name(), contents, 0600); err != nil {
+		t.Fatalf("Could not write file: %s", err)
+	}
+
+	// The change to the main database must still be reported
+	// once polling succeeds again.
+	for {
+		e := next()
+		if e.Err != nil {
+			continue
+		}
+		if e.Schema != "main" || len(e.Changes) != 1 || e.Changes[0].Name != "users" {
+			t.Fatalf("Expected users to be added to main, got %+v", e)
+		}
+		break
+	}
+}